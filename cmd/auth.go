@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/config"
+	"crm-admin/internal/credentials"
+	"crm-admin/internal/jwt"
+)
+
+// authStatus is the structured output of 'auth status'
+type authStatus struct {
+	Profile    string     `json:"profile"`
+	BaseURL    string     `json:"baseUrl"`
+	LoggedIn   bool       `json:"loggedIn"`
+	Source     string     `json:"source,omitempty"`
+	Token      string     `json:"token,omitempty"`
+	Username   string     `json:"username,omitempty"`
+	LoggedInAt *time.Time `json:"loggedInAt,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	Expired    bool       `json:"expired"`
+	Refresh    bool       `json:"refreshable"`
+	Verified   *bool      `json:"verified,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// tokenExpiryWarning is how long before a token expires commands start warning about it
+const tokenExpiryWarning = 10 * time.Minute
+
+// tokenWarning makes sure the expiry warning is shown at most once per run
+var tokenWarning sync.Once
+
+// skipTokenWarning turns the expiry warning off for commands that show the expiry
+// themselves
+var skipTokenWarning bool
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Log in to the backend and manage stored credentials",
+	Long: `Log in to the backend and manage the admin credential of the active profile.
+
+'auth login' stores the token encrypted in the config directory, one file per
+profile. The encryption key comes from a keyring:
+  file        a random key in a file next to the credentials (default)
+  passphrase  a key derived from a passphrase ($CRM_KEYRING_PASSPHRASE or prompted)
+
+$CRM_ADMIN_API_KEY and the profile's token, token_env and token_file settings
+take precedence over a stored login.
+
+JWTs are decoded locally to show their subject, scopes and expiry; commands warn
+when the token expires within 10 minutes. If the login returned a refresh token
+and the profile has a refresh_endpoint, a token the backend rejects is refreshed
+and the request is sent again.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in and store the token for the active profile",
+	Long: `Log in to the backend of the active profile and store the token encrypted.
+
+By default you are asked for an admin key. With --username the username and a
+password (asked for, or read from stdin) are exchanged for a token at the login
+endpoint instead.
+
+The token is checked against the backend before it is kept; pass --no-verify to
+skip the check.`,
+	Example: `  crm-admin auth login
+  crm-admin --profile prod auth login --keyring passphrase
+  echo "$ADMIN_KEY" | crm-admin auth login
+  crm-admin auth login --username admin`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		username, _ := cmd.Flags().GetString("username")
+		endpoint, _ := cmd.Flags().GetString("login-endpoint")
+		keyring, _ := cmd.Flags().GetString("keyring")
+		noVerify, _ := cmd.Flags().GetBool("no-verify")
+
+		profileName := config.ActiveProfileName()
+		messages := newPrinter().Messages()
+
+		if keyring != string(credentials.FileKeyring) && keyring != string(credentials.PassphraseKeyring) {
+			return fmt.Errorf("invalid --keyring '%s' (use file or passphrase)", keyring)
+		}
+
+		cred := &credentials.Credential{
+			Username:  username,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if username != "" {
+			password, err := readSecret("Password: ", "password")
+			if err != nil {
+				return err
+			}
+			if endpoint == "" {
+				endpoint = config.GetLoginEndpoint()
+			}
+
+			token, err := newAPIClient().Login(cmd.Context(), endpoint, username, password)
+			if err != nil {
+				return fmt.Errorf("failed to log in: %w", err)
+			}
+			cred.Token = token.Token
+			cred.RefreshToken = token.RefreshToken
+		} else {
+			token, err := readSecret(fmt.Sprintf("Admin key for profile '%s': ", profileName), "admin key")
+			if err != nil {
+				return err
+			}
+			cred.Token = token
+		}
+
+		// The token is checked before it is stored, so a rejected token never
+		// replaces a login that works
+		var verifyErr error
+		if !noVerify {
+			_, verifyErr = newClient().ListUsers(api.WithToken(cmd.Context(), cred.Token))
+			if api.IsUnauthorized(verifyErr) {
+				return fmt.Errorf("the backend rejected the token, nothing was stored: %w", verifyErr)
+			}
+		}
+
+		passphrase := ""
+		if keyring == string(credentials.PassphraseKeyring) {
+			var err error
+			if passphrase, err = newPassphrase(); err != nil {
+				return err
+			}
+		}
+
+		if err := credentials.Save(profileName, cred, credentials.Keyring(keyring), passphrase); err != nil {
+			return err
+		}
+		if verifyErr != nil {
+			return fmt.Errorf("failed to verify the token (it was stored anyway): %w", verifyErr)
+		}
+
+		if _, source := config.AdminToken(); source != "" {
+			fmt.Fprintf(messages, "⚠️  The token from %s takes precedence over the stored login\n", source)
+		}
+
+		fmt.Fprintf(messages, "✅ Logged in to profile '%s' (%s)\n", profileName, config.GetBaseURL())
+		fmt.Fprintf(messages, "   Token stored encrypted in %s (%s keyring)\n", credentials.Path(profileName), keyring)
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which credential the active profile uses",
+	Long: `Show where the admin token of the active profile comes from and check that
+the backend accepts it. Pass --offline to skip the check.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		offline, _ := cmd.Flags().GetBool("offline")
+		profileName := config.ActiveProfileName()
+
+		status := authStatus{
+			Profile: profileName,
+			BaseURL: config.GetBaseURL(),
+		}
+
+		token, source, err := credentials.Token()
+		if err != nil {
+			return err
+		}
+		status.LoggedIn = token != ""
+		status.Source = source
+		status.Token = maskToken(token)
+
+		if strings.HasPrefix(source, "login:") {
+			stored, _ := credentials.Load(profileName)
+			status.Username = stored.Username
+			status.LoggedInAt = &stored.CreatedAt
+			status.Refresh = stored.RefreshToken != "" && config.GetRefreshEndpoint() != ""
+		}
+
+		// Tokens that aren't JWTs are opaque; there is nothing more to show
+		if claims, err := jwt.Parse(token); err == nil {
+			status.Subject = claims.Subject
+			status.Scopes = claims.Scopes
+			if !claims.ExpiresAt.IsZero() {
+				status.ExpiresAt = &claims.ExpiresAt
+				status.Expired = claims.Expired(time.Now())
+			}
+		}
+
+		// The status shows the expiry itself
+		skipTokenWarning = true
+		defer func() { skipTokenWarning = false }()
+
+		var verifyErr error
+		if status.LoggedIn && !offline {
+			_, verifyErr = newClient().ListUsers(cmd.Context())
+			verified := verifyErr == nil
+			status.Verified = &verified
+			if verifyErr != nil {
+				status.Error = verifyErr.Error()
+			}
+		}
+
+		printErr := newPrinter().Print(status, func() {
+			fmt.Printf("🔐 Profile: %s (%s)\n", status.Profile, status.BaseURL)
+			if !status.LoggedIn {
+				fmt.Println("   Not logged in")
+				fmt.Println("\nLog in with: crm-admin auth login")
+				return
+			}
+			fmt.Printf("   Token: %s (from %s)\n", status.Token, status.Source)
+			if status.Username != "" {
+				fmt.Printf("   Username: %s\n", status.Username)
+			}
+			if status.LoggedInAt != nil {
+				fmt.Printf("   Logged in: %s\n", status.LoggedInAt.Local().Format(time.RFC1123))
+			}
+			if status.Subject != "" {
+				fmt.Printf("   Subject: %s\n", status.Subject)
+			}
+			if len(status.Scopes) > 0 {
+				fmt.Printf("   Scopes: %s\n", strings.Join(status.Scopes, ", "))
+			}
+			if status.ExpiresAt != nil {
+				fmt.Printf("   Expires: %s (%s)\n", status.ExpiresAt.Local().Format(time.RFC1123), describeExpiry(*status.ExpiresAt, time.Now()))
+			}
+			if status.Refresh {
+				fmt.Println("   Refreshed automatically when the backend rejects it")
+			}
+			switch {
+			case status.Verified == nil:
+			case *status.Verified:
+				fmt.Println("   ✅ Accepted by the backend")
+			default:
+				fmt.Printf("   ❌ Not accepted: %s\n", status.Error)
+			}
+		})
+		if printErr != nil {
+			return printErr
+		}
+
+		if !status.LoggedIn {
+			return fmt.Errorf("%w for profile '%s'", credentials.ErrNoCredential, profileName)
+		}
+		return verifyErr
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the stored token of the active profile",
+	Long:  `Remove the token stored by 'auth login' for the active profile.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName := config.ActiveProfileName()
+		messages := newPrinter().Messages()
+
+		removed, err := credentials.Delete(profileName)
+		if err != nil {
+			return err
+		}
+
+		if removed {
+			fmt.Fprintf(messages, "✅ Logged out of profile '%s'\n", profileName)
+		} else {
+			fmt.Fprintf(messages, "Profile '%s' has no stored login.\n", profileName)
+		}
+		if _, source := config.AdminToken(); source != "" {
+			fmt.Fprintf(messages, "⚠️  A token is still configured through %s\n", source)
+		}
+		return nil
+	},
+}
+
+// newPassphrase returns the passphrase for a new passphrase-protected credential
+func newPassphrase() (string, error) {
+	if passphrase := os.Getenv("CRM_KEYRING_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("set CRM_KEYRING_PASSPHRASE to use the passphrase keyring without a terminal")
+	}
+	return readPassword("Keyring passphrase: ")
+}
+
+// askPassphrase asks for the passphrase of a stored credential on the terminal
+func askPassphrase() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("the stored login is protected by a passphrase; set CRM_KEYRING_PASSPHRASE")
+	}
+	return readSecret("Keyring passphrase: ", "passphrase")
+}
+
+// warnTokenExpiry warns on stderr when the token about to be used is a JWT that has
+// expired or expires soon, unless skipTokenWarning is set. Tokens that aren't JWTs
+// are never warned about.
+func warnTokenExpiry() {
+	if skipTokenWarning {
+		return
+	}
+	tokenWarning.Do(func() {
+		token, source, err := credentials.Token()
+		if err != nil || token == "" {
+			return
+		}
+		claims, err := jwt.Parse(token)
+		if err != nil || !claims.ExpiresWithin(time.Now(), tokenExpiryWarning) {
+			return
+		}
+
+		profileName := config.ActiveProfileName()
+		action := fmt.Sprintf("replace the token from %s", source)
+		if strings.HasPrefix(source, "login:") {
+			action = "run 'crm-admin auth login' to get a new one"
+			if stored, _ := credentials.Load(profileName); stored != nil && stored.RefreshToken != "" && config.GetRefreshEndpoint() != "" {
+				action = "it will be refreshed automatically"
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "⚠️  The token of profile '%s' %s; %s\n", profileName, describeExpiry(claims.ExpiresAt, time.Now()), action)
+	})
+}
+
+// describeExpiry returns "expires in 5m0s" or "expired 2h0m0s ago"
+func describeExpiry(expiresAt, now time.Time) string {
+	if now.Before(expiresAt) {
+		return "expires in " + expiresAt.Sub(now).Round(time.Second).String()
+	}
+	return "expired " + now.Sub(expiresAt).Round(time.Second).String() + " ago"
+}
+
+// maskToken shows just enough of a token to tell tokens apart
+func maskToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 8 {
+		return "****"
+	}
+	return token[:4] + "****" + token[len(token)-4:]
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+
+	credentials.PassphrasePrompt = askPassphrase
+
+	// Flags for auth login
+	authLoginCmd.Flags().String("username", "", "Exchange this username and a password for a token instead of entering an admin key")
+	authLoginCmd.Flags().String("login-endpoint", "", "Endpoint that exchanges credentials for a token (default: the profile's login_endpoint or "+config.DefaultLoginEndpoint+")")
+	authLoginCmd.Flags().String("keyring", string(credentials.FileKeyring), "How the stored token is encrypted: file or passphrase")
+	authLoginCmd.Flags().Bool("no-verify", false, "Store the token without checking it against the backend")
+
+	// Flags for auth status
+	authStatusCmd.Flags().Bool("offline", false, "Don't check the token against the backend")
+}
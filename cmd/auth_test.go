@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/config"
+	"crm-admin/internal/credentials"
+	"crm-admin/internal/fake"
+	"crm-admin/internal/mockserver"
+)
+
+// captureStderr returns what fn writes to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = original }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	fn()
+	writer.Close()
+	return <-output
+}
+
+func TestAuthStatusSkipsTokenWarning(t *testing.T) {
+	service := setupFake(t)
+	payload := fmt.Sprintf(`{"sub":"admin","exp":%d}`, time.Now().Add(-time.Hour).Unix())
+	t.Setenv("CRM_ADMIN_API_KEY", "eyJhbGciOiJIUzI1NiJ9."+base64.RawURLEncoding.EncodeToString([]byte(payload))+".c2lnbmF0dXJl")
+
+	// Warn like *api.Client does when it is created
+	tokenWarning = sync.Once{}
+	t.Cleanup(func() { tokenWarning = sync.Once{} })
+	newClient = func() api.CRMService {
+		warnTokenExpiry()
+		return service
+	}
+
+	stderr := captureStderr(t, func() {
+		if _, err := run(t, "", "auth", "status"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.Contains(stderr, "expired") {
+		t.Fatalf("auth status warned about the expiry it shows: %q", stderr)
+	}
+
+	// Other commands still get the warning
+	stderr = captureStderr(t, warnTokenExpiry)
+	if !strings.Contains(stderr, "expired 1h") {
+		t.Fatalf("got %q, want an expiry warning", stderr)
+	}
+}
+
+func TestAuthLoginKeepsLoginWhenTokenIsRejected(t *testing.T) {
+	setupFake(t)
+	server, err := mockserver.New(fake.New(), mockserver.Options{Prefix: "/api", Token: "good-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+	t.Setenv("CRM_BACKEND_URL", httpServer.URL+"/api")
+	t.Setenv("CRM_RETRIES", "0")
+	newClient = func() api.CRMService { return newAPIClient() }
+
+	if _, err := run(t, "good-token\n", "auth", "login"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = run(t, "bad-token\n", "auth", "login")
+	if !api.IsUnauthorized(err) || !strings.Contains(err.Error(), "nothing was stored") {
+		t.Fatalf("got %v, want the token to be rejected", err)
+	}
+
+	cred, err := credentials.Load(config.ActiveProfileName())
+	if err != nil || cred == nil || cred.Token != "good-token" {
+		t.Fatalf("got credential %+v, %v; want the earlier login", cred, err)
+	}
+	mustRun(t, "user", "list")
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/backup"
+	"crm-admin/internal/config"
+	"crm-admin/internal/fsutil"
+)
+
+// backupResult is the structured output of 'backup create'
+type backupResult struct {
+	Path          string             `json:"path"`
+	SchemaVersion int                `json:"schemaVersion"`
+	Users         []backup.UserEntry `json:"users"`
+}
+
+// restoreResult is the structured output of 'backup restore'
+type restoreResult struct {
+	Report string              `json:"report"`
+	Users  []backup.UserReport `json:"users"`
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up and restore all users, contacts and notes",
+	Long: `Back up every user with their contacts and notes to a single archive, and
+restore such an archive to a backend.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up all users with their contacts and notes",
+	Long: `Write every user with their contacts and notes to a tar.gz archive.
+
+The archive holds a manifest.json, with the schema version of the archive, the
+list of users and the SHA-256 checksum of every other file, and one
+users/<id>.json file per user in the format of 'crm-admin export'.
+The contacts and notes of several users are fetched at the same time.`,
+	Example: `  crm-admin backup create
+  crm-admin backup create --file nightly.tar.gz --concurrency 8`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("file")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if path == "" {
+			path = fmt.Sprintf("crm-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+
+		client := newClient()
+
+		archive, err := backup.Create(cmd.Context(), client, concurrency)
+		if err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+		archive.Manifest.Source = config.GetBaseURL()
+
+		var buf bytes.Buffer
+		if err := archive.Write(&buf); err != nil {
+			return err
+		}
+		if err := fsutil.WriteFileAtomic(path, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+
+		result := backupResult{Path: path, SchemaVersion: archive.Manifest.SchemaVersion, Users: archive.Manifest.Users}
+
+		return newPrinter().Print(result, func() {
+			contacts, notes := 0, 0
+			for _, user := range result.Users {
+				contacts += user.Contacts
+				notes += user.Notes
+			}
+			fmt.Printf("✅ Backup written to %s\n", result.Path)
+			fmt.Printf("   Users: %d\n", len(result.Users))
+			fmt.Printf("   Contacts: %d\n", contacts)
+			fmt.Printf("   Notes: %d\n", notes)
+		})
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Restore a backup to a fresh backend",
+	Long: `Recreate every user of a backup with their contacts and notes.
+
+The backend assigns new IDs, so the contact IDs of every note are rewritten to the
+IDs of the recreated contacts. A report mapping the old user, contact and note IDs
+to the new ones is written to --report.
+
+Backups don't contain passwords: restored users get a random password that is
+listed in the report. With --ask-password, every restored user gets the password
+you type instead; it is read from a hidden prompt, or from the first line of stdin
+when stdin is not a terminal. The report is only readable by you; keep it safe.
+
+Links from notes to contacts that aren't part of the backup are left out and
+listed in the report.
+
+The checksums and schema version of the archive are checked before anything is
+created, and restoring stops if a user of the backup already exists.`,
+	Example: `  crm-admin backup restore crm-backup-20240101-120000.tar.gz
+  echo "$PASSWORD" | crm-admin backup restore nightly.tar.gz --report ids.json --ask-password`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reportPath, _ := cmd.Flags().GetString("report")
+		askPassword, _ := cmd.Flags().GetBool("ask-password")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+
+		var password string
+		if askPassword {
+			var err error
+			if password, err = readPassword("Password for restored users: "); err != nil {
+				return err
+			}
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open backup: %w", err)
+		}
+		defer file.Close()
+
+		archive, err := backup.Read(file)
+		if err != nil {
+			return err
+		}
+
+		client := newClient()
+
+		// Restoring onto existing users would mix their data with the backup's
+		existing, err := client.ListUsers(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		taken := make(map[string]bool)
+		for _, user := range existing {
+			taken[user.Username] = true
+		}
+		var conflicts []string
+		for _, user := range archive.Manifest.Users {
+			if taken[user.Username] {
+				conflicts = append(conflicts, user.Username)
+			}
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("users already exist on the backend: %s (restore needs a fresh backend)", strings.Join(conflicts, ", "))
+		}
+
+		report, restoreErr := backup.Restore(cmd.Context(), client, archive, backup.RestoreOptions{Password: password, Concurrency: concurrency})
+
+		// The report is written even when restoring failed, so it can be finished by hand
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		if err := fsutil.WriteFileAtomic(reportPath, append(data, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+
+		if restoreErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Restore incomplete; what was restored is listed in %s\n", reportPath)
+			return fmt.Errorf("failed to restore backup: %w", restoreErr)
+		}
+
+		// Passwords are only written to the report file
+		result := restoreResult{Report: reportPath, Users: slices.Clone(report.Users)}
+		for i := range result.Users {
+			result.Users[i].Password = ""
+		}
+
+		return newPrinter().Print(result, func() {
+			fmt.Printf("✅ Restored %d user(s) from %s\n", len(report.Users), args[0])
+			for _, user := range report.Users {
+				fmt.Printf("   %s: %s → %s (%d contacts, %d notes)\n", user.Username, user.OldID, user.NewID, len(user.Contacts), len(user.Notes))
+				if len(user.DroppedContacts) > 0 {
+					fmt.Printf("      ⚠️  %d note(s) linked contacts missing from the backup; the links were dropped\n", len(user.DroppedContacts))
+				}
+			}
+			fmt.Printf("\nID mapping written to %s\n", reportPath)
+			if password == "" && len(report.Users) > 0 {
+				fmt.Println("Restored users have random passwords, listed in the report.")
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	// Flags for backup create
+	backupCreateCmd.Flags().String("file", "", "File to write the backup to (default: crm-backup-<date>-<time>.tar.gz)")
+	backupCreateCmd.Flags().Int("concurrency", 4, "Number of users to fetch at the same time")
+
+	// Flags for backup restore
+	backupRestoreCmd.Flags().String("report", "restore-report.json", "File to write the mapping of old IDs to new IDs to")
+	backupRestoreCmd.Flags().Bool("ask-password", false, "Prompt for a password for every restored user (default: a random password per user)")
+	backupRestoreCmd.Flags().Int("concurrency", 4, "Number of users to restore at the same time")
+}
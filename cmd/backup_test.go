@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/backup"
+	"crm-admin/internal/fake"
+)
+
+func TestBackupCreateAndRestore(t *testing.T) {
+	source := setupFake(t)
+	ctx := t.Context()
+	alice, _ := source.CreateUser(ctx, "alice", "secret")
+	jane, _ := source.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+	source.CreateNote(ctx, "Call", "", []int{jane.ID}, alice.ID)
+
+	var created backupResult
+	runJSON(t, &created, "backup", "create", "--file", "all.tar.gz")
+	if len(created.Users) != 1 || created.Users[0].Contacts != 1 || created.Users[0].Notes != 1 {
+		t.Fatalf("unexpected backup: %+v", created)
+	}
+
+	// Restoring onto the backend that was backed up conflicts
+	if _, err := run(t, "", "backup", "restore", "all.tar.gz"); err == nil || !strings.Contains(err.Error(), "already exist") {
+		t.Fatalf("got error %v, want a conflict", err)
+	}
+
+	target := fake.New()
+	target.CreateUser(ctx, "someone-else", "secret")
+	newClient = func() api.CRMService { return target }
+
+	var restored restoreResult
+	stdout, err := run(t, "Welcome-1\n", "backup", "restore", "all.tar.gz", "--report", "ids.json", "--ask-password", "-o", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(stdout), &restored); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Users) != 1 || restored.Users[0].Password != "" {
+		t.Fatalf("unexpected result: %+v", restored)
+	}
+
+	data, err := os.ReadFile("ids.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report backup.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	user := report.Users[0]
+	if user.OldID != alice.ID || user.NewID == "" || len(user.Contacts) != 1 || len(user.Notes) != 1 {
+		t.Fatalf("unexpected report: %+v", user)
+	}
+	if target.Password(user.NewID) != "Welcome-1" {
+		t.Fatal("restored user doesn't have the given password")
+	}
+
+	notes, _ := target.ListNotesForUser(ctx, user.NewID)
+	if len(notes) != 1 || notes[0].ContactIDs[0] != user.Contacts[jane.ID] {
+		t.Fatalf("note not linked to the restored contact: %+v", notes)
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/context"
+	"crm-admin/internal/fake"
+)
+
+// setupFake points the commands at an in-memory backend and isolates config and state
+// files in temporary directories
+func setupFake(t *testing.T) *fake.Service {
+	t.Helper()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("CRM_CONFIG", "")
+	t.Setenv("CRM_PROFILE", "")
+	t.Setenv("CRM_ADMIN_API_KEY", "")
+	t.Chdir(t.TempDir())
+
+	service := fake.New()
+	original := newClient
+	newClient = func() api.CRMService {
+		// Mirror *api.Client, which picks up the selected user when it is created
+		service.SelectedUserID = ""
+		if userContext, err := context.LoadUserContext(); err == nil && userContext != nil {
+			service.SelectedUserID = userContext.UserID
+		}
+		return service
+	}
+	t.Cleanup(func() { newClient = original })
+
+	return service
+}
+
+// run executes the CLI with args and returns what it wrote to stdout
+func run(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	defer resetFlags(rootCmd)
+
+	stdout, err := capture(t, stdin, func() error {
+		rootCmd.SetArgs(args)
+		return rootCmd.ExecuteContext(stdcontext.Background())
+	})
+	return stdout, err
+}
+
+// mustRun is run for commands that are expected to succeed
+func mustRun(t *testing.T, args ...string) string {
+	t.Helper()
+	stdout, err := run(t, "", args...)
+	if err != nil {
+		t.Fatalf("crm-admin %s: %v", strings.Join(args, " "), err)
+	}
+	return stdout
+}
+
+// runJSON runs a command with -o json and decodes its output into out
+func runJSON(t *testing.T, out any, args ...string) {
+	t.Helper()
+	stdout := mustRun(t, append(args, "-o", "json")...)
+	if err := json.Unmarshal([]byte(stdout), out); err != nil {
+		t.Fatalf("crm-admin %s: invalid JSON %q: %v", strings.Join(args, " "), stdout, err)
+	}
+}
+
+// capture replaces stdin and stdout while fn runs
+func capture(t *testing.T, stdin string, fn func() error) (string, error) {
+	t.Helper()
+
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inReader, inWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(inWriter, stdin)
+	inWriter.Close()
+
+	originalStdout, originalStdin := os.Stdout, os.Stdin
+	os.Stdout, os.Stdin = outWriter, inReader
+	defer func() {
+		os.Stdout, os.Stdin = originalStdout, originalStdin
+		inReader.Close()
+	}()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(outReader)
+		output <- string(data)
+	}()
+
+	runErr := fn()
+	outWriter.Close()
+	return <-output, runErr
+}
+
+// resetFlags restores every flag to its default, since cobra keeps flag values
+// between executions of the same command tree
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
@@ -2,13 +2,26 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
-	"crm-admin/internal/api"
 	"crm-admin/internal/context"
+	"crm-admin/internal/models"
+	"crm-admin/internal/output"
 )
 
+// contactColumns are the columns available to 'contact list'
+var contactColumns = []output.Column[models.Contact]{
+	{Name: "id", Header: "ID", Numeric: true, Value: func(c models.Contact) string { return strconv.Itoa(c.ID) }},
+	{Name: "name", Header: "Name", MaxWidth: 30, Value: func(c models.Contact) string { return c.Name }},
+	{Name: "company", Header: "Company", MaxWidth: 25, Value: func(c models.Contact) string { return stringValue(c.Company) }},
+	{Name: "phone", Header: "Phone", MaxWidth: 20, Value: func(c models.Contact) string { return stringValue(c.PhoneNumber) }},
+	{Name: "email", Header: "Email", MaxWidth: 35, Value: func(c models.Contact) string { return stringValue(c.ContactEmail) }},
+	{Name: "user-id", Header: "User ID", Wide: true, Value: func(c models.Contact) string { return c.UserID }},
+}
+
 var contactCmd = &cobra.Command{
 	Use:   "contact",
 	Short: "Manage contacts",
@@ -33,7 +46,7 @@ var contactCreateCmd = &cobra.Command{
 		phoneNumber, _ := cmd.Flags().GetString("phone")
 		contactEmail, _ := cmd.Flags().GetString("email")
 
-		client := api.New()
+		client := newClient()
 
 		// Convert empty strings to nil pointers
 		var companyPtr, phonePtr, emailPtr *string
@@ -47,31 +60,32 @@ var contactCreateCmd = &cobra.Command{
 			emailPtr = &contactEmail
 		}
 
-		contact, err := client.CreateContact(args[0], userID, companyPtr, phonePtr, emailPtr)
+		contact, err := client.CreateContact(cmd.Context(), args[0], userID, companyPtr, phonePtr, emailPtr)
 		if err != nil {
 			return fmt.Errorf("failed to create contact: %w", err)
 		}
 
-		fmt.Printf("✅ Contact '%s' created successfully!\n", contact.Name)
-		fmt.Printf("   ID: %d\n", contact.ID)
-		fmt.Printf("   User ID: %s\n", contact.UserID)
-		if contact.Company != nil {
-			fmt.Printf("   Company: %s\n", *contact.Company)
-		}
-		if contact.PhoneNumber != nil {
-			fmt.Printf("   Phone: %s\n", *contact.PhoneNumber)
-		}
-		if contact.ContactEmail != nil {
-			fmt.Printf("   Email: %s\n", *contact.ContactEmail)
-		}
+		return newPrinter().Print(contact, func() {
+			fmt.Printf("✅ Contact '%s' created successfully!\n", contact.Name)
+			fmt.Printf("   ID: %d\n", contact.ID)
+			fmt.Printf("   User ID: %s\n", contact.UserID)
+			if contact.Company != nil {
+				fmt.Printf("   Company: %s\n", *contact.Company)
+			}
+			if contact.PhoneNumber != nil {
+				fmt.Printf("   Phone: %s\n", *contact.PhoneNumber)
+			}
+			if contact.ContactEmail != nil {
+				fmt.Printf("   Email: %s\n", *contact.ContactEmail)
+			}
 
-		fmt.Printf("\nYou can now create notes for this contact:\n")
-		if context.HasUserContext() {
-			fmt.Printf("   crm-admin note create \"Note Title\" \"Description\" --contact-ids %d\n", contact.ID)
-		} else {
-			fmt.Printf("   crm-admin note create \"Note Title\" \"Description\" --contact-ids %d --user-id %s\n", contact.ID, contact.UserID)
-		}
-		return nil
+			fmt.Printf("\nYou can now create notes for this contact:\n")
+			if context.HasUserContext() {
+				fmt.Printf("   crm-admin note create \"Note Title\" \"Description\" --contact-ids %d\n", contact.ID)
+			} else {
+				fmt.Printf("   crm-admin note create \"Note Title\" \"Description\" --contact-ids %d --user-id %s\n", contact.ID, contact.UserID)
+			}
+		})
 	},
 }
 
@@ -87,12 +101,19 @@ var contactListCmd = &cobra.Command{
 			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
 		}
 
-		client := api.New()
+		printer := newPrinter()
+		table, err := output.NewTablePrinter(contactColumns, tableOptions(cmd, printer))
+		if err != nil {
+			return err
+		}
+
+		client := newClient()
 
-		contacts, err := client.ListContacts(userID)
+		contacts, err := client.ListContacts(cmd.Context(), userID)
 		if err != nil {
 			return fmt.Errorf("failed to list contacts: %w", err)
 		}
+		table.Sort(contacts)
 
 		// Show which user we're listing for
 		targetUserID := userID
@@ -105,51 +126,197 @@ var contactListCmd = &cobra.Command{
 			}
 		}
 
-		if len(contacts) == 0 {
+		return printer.Print(contacts, func() {
+			if len(contacts) == 0 {
+				if username != "" {
+					fmt.Printf("No contacts found for %s (%s).\n", username, targetUserID)
+				} else {
+					fmt.Printf("No contacts found for user ID %s.\n", targetUserID)
+				}
+				return
+			}
+
 			if username != "" {
-				fmt.Printf("No contacts found for %s (%s).\n", username, targetUserID)
+				fmt.Printf("📋 Contacts for %s (%s):\n", username, targetUserID)
 			} else {
-				fmt.Printf("No contacts found for user ID %s.\n", targetUserID)
+				fmt.Printf("📋 Contacts for User %s:\n", targetUserID)
 			}
-			return nil
+			table.Render(os.Stdout, contacts)
+		})
+	},
+}
+
+var contactGetCmd = &cobra.Command{
+	Use:   "get [contact-id]",
+	Short: "Get a specific contact",
+	Long:  `Get detailed information about a specific contact by its ID.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" && !context.HasUserContext() {
+			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
 		}
 
-		if username != "" {
-			fmt.Printf("📋 Contacts for %s (%s):\n", username, targetUserID)
-		} else {
-			fmt.Printf("📋 Contacts for User %s:\n", targetUserID)
+		contactID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid contact ID '%s': %w", args[0], err)
 		}
-		fmt.Printf("%-5s | %-20s | %-15s | %-15s | %s\n",
-			"ID", "Name", "Company", "Phone", "Email")
-		fmt.Printf("%-5s | %-20s | %-15s | %-15s | %s\n",
-			"-----", "--------------------", "---------------", "---------------", "---------------")
 
-		for _, contact := range contacts {
-			company := ""
-			if contact.Company != nil {
-				company = *contact.Company
-			}
-			phone := ""
-			if contact.PhoneNumber != nil {
-				phone = *contact.PhoneNumber
+		client := newClient()
+
+		contact, err := client.GetContact(cmd.Context(), userID, contactID)
+		if err != nil {
+			return fmt.Errorf("failed to get contact: %w", err)
+		}
+
+		return newPrinter().Print(contact, func() {
+			fmt.Printf("📇 Contact Details:\n")
+			printContactDetails(contact)
+		})
+	},
+}
+
+var contactUpdateCmd = &cobra.Command{
+	Use:   "update [contact-id]",
+	Short: "Update a contact",
+	Long: `Update an existing contact. Only the fields passed as flags are changed;
+use --clear-company, --clear-phone or --clear-email to remove an optional field.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" && !context.HasUserContext() {
+			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
+		}
+
+		contactID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid contact ID '%s': %w", args[0], err)
+		}
+
+		flags := cmd.Flags()
+		for _, pair := range [][2]string{{"company", "clear-company"}, {"phone", "clear-phone"}, {"email", "clear-email"}} {
+			if flags.Changed(pair[0]) && flags.Changed(pair[1]) {
+				return fmt.Errorf("--%s and --%s cannot be used together", pair[0], pair[1])
 			}
-			email := ""
-			if contact.ContactEmail != nil {
-				email = *contact.ContactEmail
+		}
+		if !flags.Changed("name") && !flags.Changed("company") && !flags.Changed("phone") && !flags.Changed("email") &&
+			!flags.Changed("clear-company") && !flags.Changed("clear-phone") && !flags.Changed("clear-email") {
+			return fmt.Errorf("nothing to update (pass at least one of --name, --company, --phone, --email or a --clear-* flag)")
+		}
+
+		client := newClient()
+
+		// Fetch the current contact so unspecified fields keep their values
+		existing, err := client.GetContact(cmd.Context(), userID, contactID)
+		if err != nil {
+			return fmt.Errorf("failed to get contact: %w", err)
+		}
+
+		name := existing.Name
+		company := existing.Company
+		phoneNumber := existing.PhoneNumber
+		contactEmail := existing.ContactEmail
+
+		if flags.Changed("name") {
+			name, _ = flags.GetString("name")
+			if name == "" {
+				return fmt.Errorf("name cannot be empty")
 			}
+		}
+		if flags.Changed("company") {
+			value, _ := flags.GetString("company")
+			company = &value
+		}
+		if flags.Changed("phone") {
+			value, _ := flags.GetString("phone")
+			phoneNumber = &value
+		}
+		if flags.Changed("email") {
+			value, _ := flags.GetString("email")
+			contactEmail = &value
+		}
+		if clear, _ := flags.GetBool("clear-company"); clear {
+			company = nil
+		}
+		if clear, _ := flags.GetBool("clear-phone"); clear {
+			phoneNumber = nil
+		}
+		if clear, _ := flags.GetBool("clear-email"); clear {
+			contactEmail = nil
+		}
+
+		contact, err := client.UpdateContact(cmd.Context(), userID, contactID, name, company, phoneNumber, contactEmail)
+		if err != nil {
+			return fmt.Errorf("failed to update contact: %w", err)
+		}
 
-			fmt.Printf("%-5d | %-20s | %-15s | %-15s | %s\n",
-				contact.ID, contact.Name, company, phone, email)
+		return newPrinter().Print(contact, func() {
+			fmt.Printf("✅ Contact updated successfully!\n")
+			printContactDetails(contact)
+		})
+	},
+}
+
+var contactDeleteCmd = &cobra.Command{
+	Use:   "delete [contact-id]",
+	Short: "Delete a contact",
+	Long:  `Delete a contact by its ID.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" && !context.HasUserContext() {
+			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
 		}
 
-		return nil
+		contactID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid contact ID '%s': %w", args[0], err)
+		}
+
+		client := newClient()
+
+		err = client.DeleteContact(cmd.Context(), userID, contactID)
+		if err != nil {
+			return fmt.Errorf("failed to delete contact: %w", err)
+		}
+
+		result := deleteResult{Kind: "contact", ID: contactID, Deleted: true}
+
+		return newPrinter().Print(result, func() {
+			fmt.Printf("✅ Contact %d deleted successfully!\n", contactID)
+		})
 	},
 }
 
+// printContactDetails prints the fields of a single contact in the indented detail format
+func printContactDetails(contact *models.Contact) {
+	fmt.Printf("   ID: %d\n", contact.ID)
+	fmt.Printf("   Name: %s\n", contact.Name)
+	if contact.Company != nil {
+		fmt.Printf("   Company: %s\n", *contact.Company)
+	}
+	if contact.PhoneNumber != nil {
+		fmt.Printf("   Phone: %s\n", *contact.PhoneNumber)
+	}
+	if contact.ContactEmail != nil {
+		fmt.Printf("   Email: %s\n", *contact.ContactEmail)
+	}
+	fmt.Printf("   User ID: %s\n", contact.UserID)
+}
+
 func init() {
 	rootCmd.AddCommand(contactCmd)
 	contactCmd.AddCommand(contactCreateCmd)
 	contactCmd.AddCommand(contactListCmd)
+	contactCmd.AddCommand(contactGetCmd)
+	contactCmd.AddCommand(contactUpdateCmd)
+	contactCmd.AddCommand(contactDeleteCmd)
 
 	// Flags for contact create
 	contactCreateCmd.Flags().String("user-id", "", "ID of the user who owns this contact (optional if user is selected)")
@@ -159,4 +326,21 @@ func init() {
 
 	// Flags for contact list
 	contactListCmd.Flags().String("user-id", "", "ID of the user whose contacts to list (optional if user is selected)")
+	addTableFlags(contactListCmd)
+
+	// Flags for contact get
+	contactGetCmd.Flags().String("user-id", "", "ID of the user who owns the contact (optional if user is selected)")
+
+	// Flags for contact update
+	contactUpdateCmd.Flags().String("user-id", "", "ID of the user who owns the contact (optional if user is selected)")
+	contactUpdateCmd.Flags().String("name", "", "New contact name")
+	contactUpdateCmd.Flags().String("company", "", "New company name")
+	contactUpdateCmd.Flags().String("phone", "", "New phone number")
+	contactUpdateCmd.Flags().String("email", "", "New contact email")
+	contactUpdateCmd.Flags().Bool("clear-company", false, "Remove the company from the contact")
+	contactUpdateCmd.Flags().Bool("clear-phone", false, "Remove the phone number from the contact")
+	contactUpdateCmd.Flags().Bool("clear-email", false, "Remove the email from the contact")
+
+	// Flags for contact delete
+	contactDeleteCmd.Flags().String("user-id", "", "ID of the user who owns the contact (optional if user is selected)")
 }
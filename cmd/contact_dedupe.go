@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/context"
+	"crm-admin/internal/dedupe"
+	"crm-admin/internal/models"
+)
+
+var contactDedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find contacts that are probably duplicates",
+	Long: `Find groups of a user's contacts that are probably the same person.
+
+Two contacts are candidates when they have the same email address (ignoring case),
+the same phone number (ignoring formatting and a country prefix), or names that are
+at least --threshold similar. Names are compared lowercased, without punctuation and
+regardless of word order; 1 only matches equal names and lower values also match
+typos. Similar names at different companies don't count.
+
+Nothing is changed. Each group suggests the most complete contact to keep and the
+'contact merge' command that merges the others into it.`,
+	Example: `  crm-admin contact dedupe
+  crm-admin contact dedupe --user-id 123 --threshold 0.9 -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" && !context.HasUserContext() {
+			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
+		}
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("--threshold must be greater than 0 and at most 1")
+		}
+
+		client := newClient()
+
+		contacts, err := client.ListContacts(cmd.Context(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
+
+		groups := dedupe.Find(contacts, threshold)
+		if groups == nil {
+			groups = []dedupe.Group{}
+		}
+
+		return newPrinter().Print(groups, func() {
+			if len(groups) == 0 {
+				fmt.Printf("✅ No duplicates found among %d contact(s).\n", len(contacts))
+				return
+			}
+
+			fmt.Printf("🔍 Found %d group(s) of possible duplicates among %d contact(s):\n", len(groups), len(contacts))
+			for i, group := range groups {
+				fmt.Printf("\n   Group %d:\n", i+1)
+				for _, contact := range group.Contacts {
+					marker := " "
+					if contact.ID == group.KeepID {
+						marker = "*"
+					}
+					fmt.Printf("   %s %d  %s\n", marker, contact.ID, describeContact(contact))
+				}
+				for _, reason := range group.Reasons {
+					fmt.Printf("     - %s\n", reason)
+				}
+				merge := fmt.Sprintf("crm-admin contact merge %d %s", group.KeepID, strings.ReplaceAll(joinIDs(group.DuplicateIDs()), ",", " "))
+				if userID != "" {
+					merge += " --user-id " + userID
+				}
+				fmt.Printf("     Merge: %s\n", merge)
+			}
+			fmt.Printf("\n   * the most complete contact, suggested to keep\n")
+		})
+	},
+}
+
+var contactMergeCmd = &cobra.Command{
+	Use:   "merge [keep-id] [duplicate-id...]",
+	Short: "Merge duplicate contacts into one",
+	Long: `Merge duplicate contacts into the contact to keep.
+
+The kept contact keeps its name. A company, phone number or email it doesn't have
+is taken from the first duplicate, in the order given, that has one. Every note
+linking a duplicate then links the kept contact instead, and the duplicates are
+deleted.`,
+	Example: `  crm-admin contact merge 12 15 18
+  crm-admin contact merge 12 15 --user-id 123`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" && !context.HasUserContext() {
+			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
+		}
+
+		ids := make([]int, 0, len(args))
+		for _, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid contact ID '%s': %w", arg, err)
+			}
+			ids = append(ids, id)
+		}
+
+		client := newClient()
+
+		result, err := dedupe.Merge(cmd.Context(), client, userID, ids[0], ids[1:])
+		if err != nil {
+			return fmt.Errorf("failed to merge contacts: %w", err)
+		}
+
+		return newPrinter().Print(result, func() {
+			fmt.Printf("✅ Merged %d contact(s) into '%s' (ID: %d)\n", len(result.Deleted), result.Contact.Name, result.Contact.ID)
+			if len(result.FilledFields) > 0 {
+				fmt.Printf("   Filled in: %s\n", strings.Join(result.FilledFields, ", "))
+			}
+			if len(result.RelinkedNotes) > 0 {
+				fmt.Printf("   Relinked notes: %s\n", joinIDs(result.RelinkedNotes))
+			}
+			fmt.Printf("   Deleted contacts: %s\n", joinIDs(result.Deleted))
+		})
+	},
+}
+
+// describeContact formats a contact's name with the fields that are set
+func describeContact(contact models.Contact) string {
+	parts := []string{contact.Name}
+	for _, field := range []*string{contact.Company, contact.PhoneNumber, contact.ContactEmail} {
+		if field != nil && *field != "" {
+			parts = append(parts, *field)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func init() {
+	contactCmd.AddCommand(contactDedupeCmd)
+	contactCmd.AddCommand(contactMergeCmd)
+
+	// Flags for contact dedupe
+	contactDedupeCmd.Flags().String("user-id", "", "ID of the user whose contacts to check (optional if user is selected)")
+	contactDedupeCmd.Flags().Float64("threshold", dedupe.DefaultThreshold, "Name similarity from 0 to 1 above which contacts are candidates")
+
+	// Flags for contact merge
+	contactMergeCmd.Flags().String("user-id", "", "ID of the user who owns the contacts (optional if user is selected)")
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/context"
+	"crm-admin/internal/importer"
+)
+
+// Statuses of a row in the import report
+const (
+	importCreated = "created"
+	importPlanned = "planned" // would be created, in a dry run
+	importSkipped = "skipped"
+	importInvalid = "invalid"
+	importFailed  = "failed"
+	importAborted = "aborted" // not attempted because an earlier row failed
+)
+
+// importRow is the outcome of one row of an import file
+type importRow struct {
+	Row       int    `json:"row"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	ContactID int    `json:"contactId,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// importReport is the structured output of 'contact import'
+type importReport struct {
+	File    string         `json:"file"`
+	DryRun  bool           `json:"dryRun"`
+	Summary map[string]int `json:"summary"`
+	Rows    []importRow    `json:"rows"`
+}
+
+var contactImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import contacts from a CSV, JSON or NDJSON file",
+	Long: `Create a contact for every row of a CSV, JSON or NDJSON file.
+
+CSV files need a header row; JSON files hold an array of objects and NDJSON files
+one object per line. Columns named name, company, phone/phoneNumber and
+email/contactEmail are recognised (ignoring case, spaces, '-' and '_'); map other
+columns with --map "Column=field". Columns that map to no field are ignored.
+
+Every row is validated before anything is created. Without --continue-on-error an
+invalid row aborts the import, and the first row the backend rejects stops it.
+With --skip-existing, rows matching an existing contact (or an earlier row) by
+email, or by name and company, are skipped. Use --dry-run to see what would happen.
+Pass - as the file to read from standard input (requires --format).`,
+	Example: `  crm-admin contact import customers.csv --dry-run
+  crm-admin contact import customers.csv --map "Full Name=name" --map "E-mail Address=email"
+  crm-admin contact import leads.ndjson --skip-existing --continue-on-error -o json
+  cat contacts.json | crm-admin contact import - --format json --user-id 123`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" && !context.HasUserContext() {
+			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
+		}
+
+		formatFlag, _ := cmd.Flags().GetString("format")
+		mapFlags, _ := cmd.Flags().GetStringArray("map")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		skipExisting, _ := cmd.Flags().GetBool("skip-existing")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+
+		path := args[0]
+		var format importer.Format
+		var err error
+		if formatFlag != "" {
+			format, err = importer.ParseFormat(formatFlag)
+		} else if path == "-" {
+			err = fmt.Errorf("--format is required when reading from standard input")
+		} else {
+			format, err = importer.DetectFormat(path)
+		}
+		if err != nil {
+			return err
+		}
+
+		mapping, err := importer.ParseMapping(mapFlags)
+		if err != nil {
+			return err
+		}
+
+		var input io.Reader = os.Stdin
+		if path != "-" {
+			file, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open import file: %w", err)
+			}
+			defer file.Close()
+			input = file
+		}
+
+		file, err := importer.Read(input, format, mapping)
+		if err != nil {
+			return err
+		}
+
+		printer := newPrinter()
+		if len(file.Ignored) > 0 {
+			fmt.Fprintf(printer.Messages(), "⚠️  Ignoring columns that map to no field: %s\n", strings.Join(file.Ignored, ", "))
+		}
+
+		rows := make([]importRow, len(file.Rows))
+		invalid := 0
+		for i, row := range file.Rows {
+			rows[i] = importRow{Row: row.Row, Name: row.Contact.Name}
+			if row.Err != nil {
+				rows[i].Status = importInvalid
+				rows[i].Reason = row.Err.Error()
+				invalid++
+			}
+		}
+
+		client := newClient()
+
+		if skipExisting {
+			existing, err := client.ListContacts(cmd.Context(), userID)
+			if err != nil {
+				return fmt.Errorf("failed to list existing contacts: %w", err)
+			}
+
+			// Remember what each key matched, so later rows of the file are checked too
+			seen := make(map[string]string)
+			for _, contact := range existing {
+				for _, key := range importer.Key(contact.Name, contact.Company, contact.ContactEmail) {
+					seen[key] = fmt.Sprintf("matches contact %d", contact.ID)
+				}
+			}
+			for i, row := range file.Rows {
+				if rows[i].Status != "" {
+					continue
+				}
+				keys := importer.Key(row.Contact.Name, row.Contact.Company, row.Contact.ContactEmail)
+				for _, key := range keys {
+					if match, ok := seen[key]; ok {
+						rows[i].Status = importSkipped
+						rows[i].Reason = match
+						break
+					}
+				}
+				if rows[i].Status == "" {
+					for _, key := range keys {
+						seen[key] = fmt.Sprintf("duplicate of row %d", row.Row)
+					}
+				}
+			}
+		}
+
+		// Without --continue-on-error nothing is created while any row is invalid
+		var runErr error
+		switch {
+		case dryRun:
+			markPending(rows, importPlanned, "")
+		case invalid > 0 && !continueOnError:
+			markPending(rows, importAborted, "the file has invalid rows")
+		default:
+			runErr = importRows(cmd.Context(), client, userID, file.Rows, rows, concurrency, continueOnError)
+		}
+
+		report := importReport{File: path, DryRun: dryRun, Summary: make(map[string]int), Rows: rows}
+		for _, row := range rows {
+			report.Summary[row.Status]++
+		}
+
+		if err := printer.Print(report, func() { printImportReport(report) }); err != nil {
+			return err
+		}
+
+		notImported := invalid + report.Summary[importFailed]
+		switch {
+		case runErr != nil:
+			return runErr
+		case dryRun && invalid > 0:
+			return fmt.Errorf("%d of %d rows are invalid", invalid, len(rows))
+		case invalid > 0 && !continueOnError:
+			return fmt.Errorf("%d of %d rows are invalid; nothing was imported (use --continue-on-error to import the valid rows)", invalid, len(rows))
+		case notImported > 0:
+			return fmt.Errorf("%d of %d rows could not be imported", notImported, len(rows))
+		}
+		return nil
+	},
+}
+
+// importRows creates the contacts of the rows that have no status yet, with up to
+// concurrency requests in flight. Without continueOnError the first failure stops
+// the import and is returned.
+func importRows(ctx stdcontext.Context, client api.CRMService, userID string, source []importer.Row, rows []importRow, concurrency int, continueOnError bool) error {
+	ctx, cancel := stdcontext.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan int)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					rows[i].Status = importAborted
+					continue
+				}
+
+				request := source[i].Contact
+				contact, err := client.CreateContact(ctx, request.Name, userID, request.Company, request.PhoneNumber, request.ContactEmail)
+				if err != nil {
+					mu.Lock()
+					if errors.Is(err, stdcontext.Canceled) && ctx.Err() != nil {
+						rows[i].Status = importAborted
+					} else {
+						rows[i].Status = importFailed
+						rows[i].Reason = err.Error()
+						if firstErr == nil && !continueOnError {
+							firstErr = fmt.Errorf("failed to import row %d: %w", rows[i].Row, err)
+							cancel()
+						}
+					}
+					mu.Unlock()
+					continue
+				}
+				rows[i].Status = importCreated
+				rows[i].ContactID = contact.ID
+			}
+		}()
+	}
+
+	for i := range rows {
+		if rows[i].Status == "" {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// An interrupt cancels the parent context; report it as such
+	return ctx.Err()
+}
+
+// markPending gives every row without a status the given one
+func markPending(rows []importRow, status, reason string) {
+	for i := range rows {
+		if rows[i].Status == "" {
+			rows[i].Status = status
+			rows[i].Reason = reason
+		}
+	}
+}
+
+func printImportReport(report importReport) {
+	if report.DryRun {
+		fmt.Printf("🔍 Dry run of importing %s (nothing is created):\n", report.File)
+	} else {
+		fmt.Printf("📥 Import of %s:\n", report.File)
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case importCreated:
+			fmt.Printf("   ✅ Row %d: %s (ID %d)\n", row.Row, row.Name, row.ContactID)
+		case importPlanned:
+			fmt.Printf("   ➕ Row %d: %s would be created\n", row.Row, row.Name)
+		case importSkipped:
+			fmt.Printf("   ⏭️  Row %d: %s skipped, %s\n", row.Row, row.Name, row.Reason)
+		case importAborted:
+			fmt.Printf("   ⏸️  Row %d: %s not imported\n", row.Row, row.Name)
+		default:
+			fmt.Printf("   ❌ Row %d: %s %s: %s\n", row.Row, row.Name, row.Status, row.Reason)
+		}
+	}
+
+	var parts []string
+	for _, status := range []string{importCreated, importPlanned, importSkipped, importInvalid, importFailed, importAborted} {
+		if count := report.Summary[status]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, status))
+		}
+	}
+	if len(parts) == 0 {
+		fmt.Println("\nThe file has no rows.")
+		return
+	}
+	fmt.Printf("\nSummary: %s\n", strings.Join(parts, ", "))
+}
+
+func init() {
+	contactCmd.AddCommand(contactImportCmd)
+
+	// Flags for contact import
+	contactImportCmd.Flags().String("user-id", "", "ID of the user who will own the contacts (optional if user is selected)")
+	contactImportCmd.Flags().String("format", "", "Format of the file: csv, json or ndjson (default: from the file extension)")
+	contactImportCmd.Flags().StringArray("map", []string{}, "Map a column to a contact field, as \"Column=field\" (repeatable)")
+	contactImportCmd.Flags().Bool("dry-run", false, "Validate the file and show what would be imported without creating anything")
+	contactImportCmd.Flags().Bool("continue-on-error", false, "Import the valid rows even if some rows are invalid or fail")
+	contactImportCmd.Flags().Bool("skip-existing", false, "Skip rows matching an existing contact by email, or by name and company")
+	contactImportCmd.Flags().Int("concurrency", 4, "Number of contacts to create at the same time")
+}
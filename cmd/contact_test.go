@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/models"
+)
+
+func TestContactCreate(t *testing.T) {
+	service := setupFake(t)
+	alice, _ := service.CreateUser(t.Context(), "alice", "secret")
+
+	tests := []struct {
+		name        string
+		args        []string
+		selectFirst bool
+		wantErr     string
+		wantCompany string
+	}{
+		{name: "with user ID", args: []string{"Jane", "--user-id", alice.ID, "--company", "Acme"}, wantCompany: "Acme"},
+		{name: "selected user", args: []string{"John"}, selectFirst: true},
+		{name: "no user", args: []string{"Nobody"}, wantErr: "user-id flag is required"},
+		{name: "unknown user", args: []string{"Ghost", "--user-id", "missing"}, wantErr: "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.selectFirst {
+				mustRun(t, "user", "select", alice.ID)
+				defer mustRun(t, "user", "exit")
+			}
+
+			stdout, err := run(t, "", append([]string{"contact", "create", "-o", "json"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout, `"userId": "`+alice.ID+`"`) {
+				t.Fatalf("contact not created for alice: %s", stdout)
+			}
+			if tt.wantCompany != "" && !strings.Contains(stdout, `"company": "`+tt.wantCompany+`"`) {
+				t.Fatalf("company missing: %s", stdout)
+			}
+		})
+	}
+}
+
+func TestContactUpdate(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	company, email := "Acme", "jane@acme.test"
+	contact, _ := service.CreateContact(ctx, "Jane", alice.ID, &company, nil, &email)
+	id := strconv.Itoa(contact.ID)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+		check   func(models.Contact) bool
+	}{
+		{
+			name:  "rename keeps other fields",
+			args:  []string{"--name", "Jane Doe"},
+			check: func(c models.Contact) bool { return c.Name == "Jane Doe" && stringValue(c.Company) == "Acme" },
+		},
+		{
+			name:  "clear email",
+			args:  []string{"--clear-email"},
+			check: func(c models.Contact) bool { return c.ContactEmail == nil && stringValue(c.Company) == "Acme" },
+		},
+		{name: "conflicting flags", args: []string{"--email", "x@y.test", "--clear-email"}, wantErr: "cannot be used together"},
+		{name: "nothing to update", args: nil, wantErr: "nothing to update"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"contact", "update", id, "--user-id", alice.ID}, tt.args...)
+			if tt.wantErr != "" {
+				_, err := run(t, "", args...)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			var updated models.Contact
+			runJSON(t, &updated, args...)
+			if !tt.check(updated) {
+				t.Fatalf("unexpected contact: %+v", updated)
+			}
+		})
+	}
+}
+
+func TestContactListAndDelete(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	bob, _ := service.CreateUser(ctx, "bob", "secret")
+	service.CreateContact(ctx, "Zed", alice.ID, nil, nil, nil)
+	first, _ := service.CreateContact(ctx, "Amy", alice.ID, nil, nil, nil)
+	service.CreateContact(ctx, "Bob's contact", bob.ID, nil, nil, nil)
+
+	var contacts []models.Contact
+	runJSON(t, &contacts, "contact", "list", "--user-id", alice.ID, "--sort-by", "name")
+	if len(contacts) != 2 || contacts[0].Name != "Amy" || contacts[1].Name != "Zed" {
+		t.Fatalf("unexpected contacts: %+v", contacts)
+	}
+
+	var fetched models.Contact
+	runJSON(t, &fetched, "contact", "get", strconv.Itoa(first.ID), "--user-id", alice.ID)
+	if fetched.Name != "Amy" {
+		t.Fatalf("unexpected contact: %+v", fetched)
+	}
+
+	// Contacts are only reachable through their owner
+	_, err := run(t, "", "contact", "delete", strconv.Itoa(first.ID), "--user-id", bob.ID)
+	if !api.IsNotFound(err) {
+		t.Fatalf("deleting another user's contact: got %v, want not found", err)
+	}
+
+	mustRun(t, "contact", "delete", strconv.Itoa(first.ID), "--user-id", alice.ID)
+	if _, err := service.GetContact(ctx, alice.ID, first.ID); !api.IsNotFound(err) {
+		t.Fatalf("contact still exists: %v", err)
+	}
+}
+
+func TestContactImport(t *testing.T) {
+	const csvFile = "name,company,email\nJane,Acme,jane@acme.test\nJohn,Initech,\nBad,,not-an-email\n"
+
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		args        []string
+		existing    []string
+		wantErr     string
+		wantStatus  []string
+		wantCreated []string
+	}{
+		{
+			name:        "csv",
+			file:        "contacts.csv",
+			content:     "name,company\nJane,Acme\nJohn,Initech\n",
+			wantStatus:  []string{importCreated, importCreated},
+			wantCreated: []string{"Jane", "John"},
+		},
+		{
+			name:       "invalid row aborts",
+			file:       "contacts.csv",
+			content:    csvFile,
+			wantErr:    "1 of 3 rows are invalid; nothing was imported",
+			wantStatus: []string{importAborted, importAborted, importInvalid},
+		},
+		{
+			name:        "continue on error",
+			file:        "contacts.csv",
+			content:     csvFile,
+			args:        []string{"--continue-on-error"},
+			wantErr:     "1 of 3 rows could not be imported",
+			wantStatus:  []string{importCreated, importCreated, importInvalid},
+			wantCreated: []string{"Jane", "John"},
+		},
+		{
+			name:       "dry run",
+			file:       "contacts.csv",
+			content:    "name\nJane\n",
+			args:       []string{"--dry-run"},
+			wantStatus: []string{importPlanned},
+		},
+		{
+			name:        "skip existing",
+			file:        "contacts.ndjson",
+			content:     `{"name":"Someone","contactEmail":"JANE@acme.test"}` + "\n" + `{"name":"john","company":"initech"}` + "\n" + `{"name":"Ann"}` + "\n" + `{"name":"ann"}` + "\n",
+			args:        []string{"--skip-existing"},
+			existing:    []string{"Jane", "John"},
+			wantStatus:  []string{importSkipped, importSkipped, importCreated, importSkipped},
+			wantCreated: []string{"Ann"},
+		},
+		{
+			name:        "mapping",
+			file:        "contacts.json",
+			content:     `[{"Full Name":"Jane Doe","Mail":"jane@acme.test"}]`,
+			args:        []string{"--map", "Full Name=name", "--map", "Mail=email"},
+			wantStatus:  []string{importCreated},
+			wantCreated: []string{"Jane Doe"},
+		},
+		{name: "unknown format", file: "contacts.txt", content: "name\nJane\n", wantErr: "use --format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := setupFake(t)
+			ctx := t.Context()
+			alice, _ := service.CreateUser(ctx, "alice", "secret")
+			companies := map[string]string{"Jane": "Acme", "John": "Initech"}
+			for _, name := range tt.existing {
+				company, email := companies[name], strings.ToLower(name)+"@"+strings.ToLower(companies[name])+".test"
+				service.CreateContact(ctx, name, alice.ID, &company, nil, &email)
+			}
+			before, _ := service.ListContacts(ctx, alice.ID)
+
+			if err := os.WriteFile(tt.file, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			stdout, err := run(t, "", append([]string{"contact", "import", tt.file, "--user-id", alice.ID, "-o", "json"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantStatus != nil {
+				var report importReport
+				if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+					t.Fatalf("invalid report: %v\n%s", err, stdout)
+				}
+				var statuses []string
+				for _, row := range report.Rows {
+					statuses = append(statuses, row.Status)
+				}
+				if strings.Join(statuses, ",") != strings.Join(tt.wantStatus, ",") {
+					t.Fatalf("statuses = %v, want %v", statuses, tt.wantStatus)
+				}
+			}
+
+			after, _ := service.ListContacts(ctx, alice.ID)
+			var created []string
+			for _, contact := range after[len(before):] {
+				created = append(created, contact.Name)
+			}
+			sort.Strings(created)
+			if strings.Join(created, ",") != strings.Join(tt.wantCreated, ",") {
+				t.Fatalf("created %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestContactTransfer(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantErr      string
+		wantBob      int
+		wantAlice    int
+		wantContains string
+	}{
+		{name: "dry run", args: []string{"--all", "--dry-run"}, wantBob: 0, wantAlice: 2, wantContains: `"dryRun": true`},
+		{name: "copy selected", args: []string{"--ids", "1"}, wantBob: 1, wantAlice: 2, wantContains: `"contacts": {`},
+		{name: "move all", args: []string{"--all", "--delete-originals"}, wantBob: 2, wantAlice: 0, wantContains: `"deletedContacts"`},
+		{name: "ids and all", args: []string{"--all", "--ids", "1"}, wantErr: "either --ids or --all"},
+		{name: "neither", wantErr: "either --ids or --all"},
+		{name: "foreign contact", args: []string{"--ids", "99"}, wantErr: "does not belong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := setupFake(t)
+			ctx := t.Context()
+			alice, _ := service.CreateUser(ctx, "alice", "secret")
+			bob, _ := service.CreateUser(ctx, "bob", "secret")
+			jane, _ := service.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+			service.CreateContact(ctx, "John", alice.ID, nil, nil, nil)
+			service.CreateNote(ctx, "Call", "", []int{jane.ID}, alice.ID)
+
+			stdout, err := run(t, "", append([]string{"contact", "transfer", "--from", "alice", "--to", bob.ID, "-o", "json"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout, tt.wantContains) {
+				t.Fatalf("output lacks %s:\n%s", tt.wantContains, stdout)
+			}
+
+			bobContacts, _ := service.ListContacts(ctx, bob.ID)
+			aliceContacts, _ := service.ListContacts(ctx, alice.ID)
+			if len(bobContacts) != tt.wantBob || len(aliceContacts) != tt.wantAlice {
+				t.Fatalf("bob has %d contacts and alice %d, want %d and %d", len(bobContacts), len(aliceContacts), tt.wantBob, tt.wantAlice)
+			}
+		})
+	}
+}
+
+func TestContactDedupeAndMerge(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	email := "jane@acme.test"
+	jane, _ := service.CreateContact(ctx, "Jane Smith", alice.ID, nil, nil, nil)
+	dup, _ := service.CreateContact(ctx, "jane smyth", alice.ID, nil, nil, &email)
+	service.CreateContact(ctx, "John", alice.ID, nil, nil, nil)
+	note, _ := service.CreateNote(ctx, "Call", "", []int{dup.ID}, alice.ID)
+
+	var groups []struct {
+		KeepID   int `json:"keepId"`
+		Contacts []models.Contact
+	}
+	runJSON(t, &groups, "contact", "dedupe", "--user-id", alice.ID)
+	if len(groups) != 1 || len(groups[0].Contacts) != 2 || groups[0].KeepID != dup.ID {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+
+	runJSON(t, &groups, "contact", "dedupe", "--user-id", alice.ID, "--threshold", "1")
+	if len(groups) != 0 {
+		t.Fatalf("exact names should not match: %+v", groups)
+	}
+
+	if _, err := run(t, "", "contact", "dedupe", "--user-id", alice.ID, "--threshold", "1.5"); err == nil {
+		t.Fatal("threshold above 1 accepted")
+	}
+	if _, err := run(t, "", "contact", "merge", strconv.Itoa(jane.ID), "x", "--user-id", alice.ID); err == nil || !strings.Contains(err.Error(), "invalid contact ID") {
+		t.Fatalf("got %v, want an invalid contact ID error", err)
+	}
+
+	mustRun(t, "contact", "merge", strconv.Itoa(jane.ID), strconv.Itoa(dup.ID), "--user-id", alice.ID)
+
+	merged, _ := service.GetContact(ctx, alice.ID, jane.ID)
+	if stringValue(merged.ContactEmail) != email {
+		t.Fatalf("email not filled in: %+v", merged)
+	}
+	if _, err := service.GetContact(ctx, alice.ID, dup.ID); !api.IsNotFound(err) {
+		t.Fatalf("duplicate not deleted: %v", err)
+	}
+	if relinked, _ := service.GetNote(ctx, alice.ID, note.ID); len(relinked.ContactIDs) != 1 || relinked.ContactIDs[0] != jane.ID {
+		t.Fatalf("note not relinked: %+v", relinked)
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/models"
+	"crm-admin/internal/transfer"
+)
+
+// transferResult is the structured output of 'contact transfer'
+type transferResult struct {
+	DryRun bool `json:"dryRun"`
+	*transfer.Plan
+	Result *transfer.Result `json:"result,omitempty"`
+}
+
+var contactTransferCmd = &cobra.Command{
+	Use:   "transfer",
+	Short: "Move contacts and their notes to another user",
+	Long: `Move contacts from one user to another, for example when a sales rep leaves.
+
+Contacts belong to a user, so they are recreated under the target user and get new
+IDs. Notes that only link transferred contacts are recreated for the target user
+with the new contact IDs. Notes that also link contacts that stay are copied, with
+only the transferred contacts linked.
+
+The originals are kept unless --delete-originals is given; then moved notes and
+the transferred contacts are deleted from the source user, and shared notes are
+unlinked from the transferred contacts. This only happens after everything has
+been copied.
+
+If recreating a contact or note fails, everything created so far is deleted again.
+Use --dry-run to see the plan without changing anything. --from and --to accept a
+user ID, username or unique ID prefix.`,
+	Example: `  crm-admin contact transfer --from alice --to bob --all --dry-run
+  crm-admin contact transfer --from alice --to bob --ids 12,15 --delete-originals`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromRef, _ := cmd.Flags().GetString("from")
+		toRef, _ := cmd.Flags().GetString("to")
+		idsStr, _ := cmd.Flags().GetStringSlice("ids")
+		all, _ := cmd.Flags().GetBool("all")
+		deleteOriginals, _ := cmd.Flags().GetBool("delete-originals")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if all == (len(idsStr) > 0) {
+			return fmt.Errorf("pass either --ids or --all")
+		}
+
+		// Parse contact IDs; nil selects all contacts
+		var contactIDs []int
+		for _, idStr := range idsStr {
+			id, err := strconv.Atoi(idStr)
+			if err != nil {
+				return fmt.Errorf("invalid contact ID '%s': %w", idStr, err)
+			}
+			contactIDs = append(contactIDs, id)
+		}
+
+		client := newClient()
+
+		from, err := resolveUser(cmd.Context(), client, fromRef)
+		if err != nil {
+			return err
+		}
+		to, err := resolveUser(cmd.Context(), client, toRef)
+		if err != nil {
+			return err
+		}
+
+		plan, err := transfer.NewPlan(cmd.Context(), client, *from, *to, contactIDs, deleteOriginals)
+		if err != nil {
+			return err
+		}
+
+		printer := newPrinter()
+		output := transferResult{DryRun: dryRun, Plan: plan}
+
+		if dryRun {
+			return printer.Print(output, func() {
+				fmt.Printf("🔍 Transfer plan (nothing is changed):\n")
+				printTransferPlan(plan)
+			})
+		}
+
+		result, err := transfer.Execute(cmd.Context(), client, plan)
+		if err != nil {
+			return err
+		}
+		output.Result = result
+
+		return printer.Print(output, func() {
+			fmt.Printf("✅ Transferred %d contact(s) from %s (%s) to %s (%s)\n", len(plan.Contacts), plan.From.Username, plan.From.ID, plan.To.Username, plan.To.ID)
+			for _, contact := range plan.Contacts {
+				fmt.Printf("   Contact %d → %d: %s\n", contact.ID, result.Contacts[contact.ID], contact.Name)
+			}
+			for _, note := range slices.Concat(plan.MovedNotes, plan.SharedNotes) {
+				fmt.Printf("   Note %d → %d: %s\n", note.ID, result.Notes[note.ID], note.Title)
+			}
+			if plan.DeleteOriginals {
+				fmt.Printf("   Originals deleted: %d contact(s), %d note(s); %d shared note(s) unlinked\n",
+					len(result.DeletedContacts), len(result.DeletedNotes), len(result.UnlinkedNotes))
+			} else {
+				fmt.Printf("   Originals kept (use --delete-originals to remove them)\n")
+			}
+		})
+	},
+}
+
+func printTransferPlan(plan *transfer.Plan) {
+	fmt.Printf("   From: %s (%s)\n", plan.From.Username, plan.From.ID)
+	fmt.Printf("   To: %s (%s)\n", plan.To.Username, plan.To.ID)
+
+	fmt.Printf("\n   Contacts to recreate (%d):\n", len(plan.Contacts))
+	for _, contact := range plan.Contacts {
+		fmt.Printf("     %d  %s\n", contact.ID, contact.Name)
+	}
+	printNoteList := func(title string, notes []models.Note) {
+		if len(notes) == 0 {
+			return
+		}
+		fmt.Printf("\n   %s (%d):\n", title, len(notes))
+		for _, note := range notes {
+			fmt.Printf("     %d  %s (contacts %s)\n", note.ID, note.Title, joinIDs(note.ContactIDs))
+		}
+	}
+	printNoteList("Notes to move", plan.MovedNotes)
+	printNoteList("Notes to copy, as they also link contacts that stay", plan.SharedNotes)
+
+	fmt.Println()
+	if plan.DeleteOriginals {
+		fmt.Printf("   The originals are deleted after everything has been copied.\n")
+	} else {
+		fmt.Printf("   The originals are kept.\n")
+	}
+}
+
+func init() {
+	contactCmd.AddCommand(contactTransferCmd)
+
+	// Flags for contact transfer
+	contactTransferCmd.Flags().String("from", "", "User to move the contacts from")
+	contactTransferCmd.Flags().String("to", "", "User to move the contacts to")
+	contactTransferCmd.Flags().StringSlice("ids", []string{}, "Comma-separated IDs of the contacts to move")
+	contactTransferCmd.Flags().Bool("all", false, "Move all contacts of the source user")
+	contactTransferCmd.Flags().Bool("delete-originals", false, "Delete the original contacts and moved notes after copying them")
+	contactTransferCmd.Flags().Bool("dry-run", false, "Show the plan without changing anything")
+	contactTransferCmd.MarkFlagRequired("from")
+	contactTransferCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"crm-admin/internal/context"
+	"crm-admin/internal/export"
+	"crm-admin/internal/fsutil"
+)
+
+// exportResult is the structured output of 'export' when it writes to a file
+type exportResult struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Format   string `json:"format"`
+	Path     string `json:"path"`
+	Contacts int    `json:"contacts"`
+	Notes    int    `json:"notes"`
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a user with all their contacts and notes",
+	Long: `Export a user's full dataset: the user, all their contacts, and all their notes
+with the IDs of the contacts they are linked to.
+
+Formats:
+  json                one document with the user, contacts and notes
+  ndjson              one line per record, tagged with "type": user, contact or note
+  csv                 a directory with user.csv, contacts.csv, notes.csv and
+                      note_contacts.csv, the join table linking notes to contacts
+  xlsx-free-csv-zip   the same CSV files in a zip archive
+
+Records are sorted by ID, so exports of the same data are identical and can be
+diffed. json, ndjson and zip exports are written to standard output unless --file
+is given; csv exports need --file naming the directory to write to.`,
+	Example: `  crm-admin export --user-id 123 > alice.json
+  crm-admin export --user-id 123 --format ndjson --file alice.ndjson
+  crm-admin export --format csv --file ./alice-export
+  crm-admin export --user-id 123 --format xlsx-free-csv-zip --file alice.zip`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+		formatFlag, _ := cmd.Flags().GetString("format")
+		path, _ := cmd.Flags().GetString("file")
+
+		// Check if user-id is provided or if we have context
+		if userID == "" {
+			userContext, _ := context.LoadUserContext()
+			if userContext == nil {
+				return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
+			}
+			userID = userContext.UserID
+		}
+
+		format, err := export.ParseFormat(formatFlag)
+		if err != nil {
+			return err
+		}
+		if format == export.CSV && path == "" {
+			return fmt.Errorf("--file is required with the csv format (the directory to write the CSV files to)")
+		}
+		if format == export.CSVZip && path == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("refusing to write a zip archive to the terminal; use --file or redirect the output")
+		}
+
+		client := newClient()
+
+		dataset, err := export.Fetch(cmd.Context(), client, userID)
+		if err != nil {
+			return err
+		}
+
+		var write func(io.Writer) error
+		switch format {
+		case export.JSON:
+			write = dataset.WriteJSON
+		case export.NDJSON:
+			write = dataset.WriteNDJSON
+		case export.CSVZip:
+			write = dataset.WriteZip
+		}
+
+		switch {
+		case format == export.CSV:
+			err = dataset.WriteCSV(path)
+		case path == "":
+			// The export itself goes to stdout, so there is nothing else to print
+			return write(os.Stdout)
+		default:
+			var buf bytes.Buffer
+			if err = write(&buf); err == nil {
+				err = fsutil.WriteFileAtomic(path, buf.Bytes(), 0600)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+
+		result := exportResult{
+			UserID:   dataset.User.ID,
+			Username: dataset.User.Username,
+			Format:   string(format),
+			Path:     path,
+			Contacts: len(dataset.Contacts),
+			Notes:    len(dataset.Notes),
+		}
+
+		return newPrinter().Print(result, func() {
+			fmt.Printf("✅ Exported %s (%s) to %s\n", result.Username, result.UserID, result.Path)
+			fmt.Printf("   Format: %s\n", result.Format)
+			fmt.Printf("   Contacts: %d\n", result.Contacts)
+			fmt.Printf("   Notes: %d\n", result.Notes)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	// Flags for export
+	exportCmd.Flags().String("user-id", "", "ID of the user to export (optional if user is selected)")
+	exportCmd.Flags().String("format", string(export.JSON), "Export format: json, ndjson, csv or xlsx-free-csv-zip")
+	exportCmd.Flags().String("file", "", "File to write the export to, or the directory for csv (default: standard output)")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/export"
+)
+
+func TestExport(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	bob, _ := service.CreateUser(ctx, "bob", "secret")
+	jane, _ := service.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+	john, _ := service.CreateContact(ctx, "John", alice.ID, nil, nil, nil)
+	service.CreateContact(ctx, "Other", bob.ID, nil, nil, nil)
+	service.CreateNote(ctx, "Call", "", []int{john.ID, jane.ID}, alice.ID)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+		check   func(t *testing.T, stdout string)
+	}{
+		{
+			name: "json to stdout",
+			args: []string{"--user-id", alice.ID},
+			check: func(t *testing.T, stdout string) {
+				var dataset export.Dataset
+				if err := json.Unmarshal([]byte(stdout), &dataset); err != nil {
+					t.Fatal(err)
+				}
+				if dataset.User.Username != "alice" || len(dataset.Contacts) != 2 || len(dataset.Notes) != 1 {
+					t.Fatalf("unexpected dataset: %+v", dataset)
+				}
+				if dataset.User.Password != "" {
+					t.Fatal("export contains the password")
+				}
+				if ids := dataset.Notes[0].ContactIDs; ids[0] != jane.ID || ids[1] != john.ID {
+					t.Fatalf("contact IDs not sorted: %v", ids)
+				}
+			},
+		},
+		{
+			name: "ndjson to file",
+			args: []string{"--user-id", alice.ID, "--format", "ndjson", "--file", "alice.ndjson", "-o", "json"},
+			check: func(t *testing.T, stdout string) {
+				if !strings.Contains(stdout, `"contacts": 2`) {
+					t.Fatalf("unexpected result: %s", stdout)
+				}
+				data, err := os.ReadFile("alice.ndjson")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if lines := strings.Count(string(data), "\n"); lines != 4 {
+					t.Fatalf("got %d lines, want 4:\n%s", lines, data)
+				}
+			},
+		},
+		{
+			name: "csv directory",
+			args: []string{"--user-id", alice.ID, "--format", "csv", "--file", "alice"},
+			check: func(t *testing.T, stdout string) {
+				data, err := os.ReadFile(filepath.Join("alice", "note_contacts.csv"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Count(string(data), "\n") != 3 {
+					t.Fatalf("unexpected join table:\n%s", data)
+				}
+			},
+		},
+		{name: "csv needs a directory", args: []string{"--user-id", alice.ID, "--format", "csv"}, wantErr: "--file is required"},
+		{name: "unknown format", args: []string{"--user-id", alice.ID, "--format", "xlsx"}, wantErr: "unknown export format"},
+		{name: "no user", wantErr: "user-id flag is required"},
+		{name: "unknown user", args: []string{"--user-id", "missing"}, wantErr: "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, err := run(t, "", append([]string{"export"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, stdout)
+		})
+	}
+}
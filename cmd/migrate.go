@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/config"
+	"crm-admin/internal/export"
+	"crm-admin/internal/migrate"
+)
+
+// migrateResult is the structured output of 'migrate'
+type migrateResult struct {
+	FromProfile  string `json:"fromProfile"`
+	ToProfile    string `json:"toProfile"`
+	SourceUserID string `json:"sourceUserId"`
+	StateFile    string `json:"stateFile"`
+	Anonymized   bool   `json:"anonymized"`
+	*migrate.Result
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy a user with their contacts and notes to another profile's backend",
+	Long: `Copy a user, their contacts and their notes from the backend of one profile to
+the backend of another, for example from prod to staging to reproduce a bug.
+
+The target backend assigns new IDs, so the contact IDs of every note are rewritten
+to the IDs of the copied contacts; links to contacts that aren't part of the
+source are dropped and listed. Which source record became which target record is
+kept in a state file; running the same migration again updates the records it
+copied before instead of duplicating them, and resumes an interrupted migration.
+The state file defaults to migrations/<from>-to-<to>-<user-id>.json next to the
+config file.
+
+--anonymize replaces the username and every name, company, phone number, email,
+title and description with made-up values before anything leaves the source.
+
+A new target user gets a random password that is printed once. With
+--ask-password it gets the password you type instead; it is read from a hidden
+prompt, or from the first line of stdin when stdin is not a terminal.`,
+	Example: `  crm-admin migrate --from-profile prod --to-profile staging --user-id 123
+  crm-admin migrate --from-profile prod --to-profile dev --user-id 123 --anonymize
+  echo "$PASSWORD" | crm-admin migrate --from-profile prod --to-profile dev --user-id 123 --ask-password`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromProfile, _ := cmd.Flags().GetString("from-profile")
+		toProfile, _ := cmd.Flags().GetString("to-profile")
+		userID, _ := cmd.Flags().GetString("user-id")
+		anonymize, _ := cmd.Flags().GetBool("anonymize")
+		statePath, _ := cmd.Flags().GetString("state")
+		askPassword, _ := cmd.Flags().GetBool("ask-password")
+
+		if fromProfile == toProfile {
+			return fmt.Errorf("--from-profile and --to-profile must be different profiles")
+		}
+		if backendURL != "" || os.Getenv("CRM_BACKEND_URL") != "" {
+			return fmt.Errorf("--backend-url and $CRM_BACKEND_URL would apply to both profiles; unset them to migrate between profiles")
+		}
+
+		var password string
+		if askPassword {
+			var err error
+			if password, err = readPassword("Password for the target user: "); err != nil {
+				return err
+			}
+		}
+
+		printer := newPrinter()
+		if os.Getenv("CRM_ADMIN_API_KEY") != "" {
+			fmt.Fprintln(printer.Messages(), "⚠️  $CRM_ADMIN_API_KEY is set and is used as the token of both profiles")
+		}
+
+		// Requests take their backend and token from the active profile, so each
+		// profile is activated while its client is in use
+		defer config.SetProfile(profile)
+
+		config.SetProfile(fromProfile)
+		if err := config.CheckActiveProfile(); err != nil {
+			return err
+		}
+		dataset, err := export.Fetch(cmd.Context(), newClient(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to read user %s from '%s': %w", userID, fromProfile, err)
+		}
+		if anonymize {
+			dataset = migrate.Anonymize(dataset)
+		}
+
+		config.SetProfile(toProfile)
+		if err := config.CheckActiveProfile(); err != nil {
+			return err
+		}
+
+		if statePath == "" {
+			statePath = filepath.Join(config.Dir(), "migrations", fmt.Sprintf("%s-to-%s-%s.json", fromProfile, toProfile, filepath.Base(userID)))
+		}
+		state, err := migrate.LoadState(statePath, fromProfile, toProfile, userID)
+		if err != nil {
+			return err
+		}
+		state.Anonymized = anonymize
+
+		result, err := migrate.Apply(cmd.Context(), newClient(), dataset, state, migrate.Options{
+			Password: password,
+			Save:     func(state *migrate.State) error { return state.Save(statePath) },
+		})
+		if err != nil {
+			if result != nil && result.Password != "" {
+				fmt.Fprintf(printer.Messages(), "⚠️  User %s was created with password %s\n", result.TargetUserID, result.Password)
+			}
+			return fmt.Errorf("failed to migrate to '%s' (run the same command again to resume): %w", toProfile, err)
+		}
+
+		output := migrateResult{
+			FromProfile:  fromProfile,
+			ToProfile:    toProfile,
+			SourceUserID: userID,
+			StateFile:    statePath,
+			Anonymized:   anonymize,
+			Result:       result,
+		}
+
+		return printer.Print(output, func() {
+			fmt.Printf("✅ Migrated user %s from '%s' to '%s'\n", userID, fromProfile, toProfile)
+			if result.UserCreated {
+				fmt.Printf("   Created user %s (%s)\n", result.Username, result.TargetUserID)
+				if result.Password != "" {
+					fmt.Printf("   Password: %s (shown only once)\n", result.Password)
+				}
+			} else {
+				fmt.Printf("   Updated user %s (%s)\n", result.Username, result.TargetUserID)
+			}
+			fmt.Printf("   Contacts: %d created, %d updated, %d unchanged\n", result.Contacts.Created, result.Contacts.Updated, result.Contacts.Unchanged)
+			fmt.Printf("   Notes: %d created, %d updated, %d unchanged\n", result.Notes.Created, result.Notes.Updated, result.Notes.Unchanged)
+			for _, noteID := range slices.Sorted(maps.Keys(result.DroppedContacts)) {
+				fmt.Printf("   ⚠️  Note %d links contact(s) %s, which aren't part of the source; the links were dropped\n", noteID, joinIDs(result.DroppedContacts[noteID]))
+			}
+			fmt.Printf("   State file: %s\n", statePath)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	// Flags for migrate
+	migrateCmd.Flags().String("from-profile", "", "Profile of the backend to copy from")
+	migrateCmd.Flags().String("to-profile", "", "Profile of the backend to copy to")
+	migrateCmd.Flags().String("user-id", "", "ID of the user to copy, on the source backend")
+	migrateCmd.Flags().Bool("anonymize", false, "Replace names, companies, phone numbers, emails, titles and descriptions with made-up values")
+	migrateCmd.Flags().String("state", "", "State file mapping source IDs to target IDs (default: migrations/<from>-to-<to>-<user-id>.json in the config directory)")
+	migrateCmd.Flags().Bool("ask-password", false, "Prompt for a password for the target user if it has to be created (default: random)")
+	migrateCmd.MarkFlagRequired("from-profile")
+	migrateCmd.MarkFlagRequired("to-profile")
+	migrateCmd.MarkFlagRequired("user-id")
+}
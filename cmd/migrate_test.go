@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/config"
+	"crm-admin/internal/fake"
+)
+
+func TestMigrate(t *testing.T) {
+	prod := setupFake(t)
+	staging := fake.New()
+	newClient = func() api.CRMService {
+		if config.ActiveProfileName() == "staging" {
+			return staging
+		}
+		return prod
+	}
+
+	mustRun(t, "profile", "add", "prod", "--base-url", "http://prod.test")
+	mustRun(t, "profile", "add", "staging", "--base-url", "http://staging.test")
+
+	ctx := t.Context()
+	alice, _ := prod.CreateUser(ctx, "alice", "secret")
+	jane, _ := prod.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+	prod.CreateNote(ctx, "Call", "", []int{jane.ID}, alice.ID)
+
+	args := []string{"migrate", "--from-profile", "prod", "--to-profile", "staging", "--user-id", alice.ID}
+
+	var first migrateResult
+	runJSON(t, &first, append(args, "--anonymize")...)
+	if !first.UserCreated || first.Contacts.Created != 1 || first.Notes.Created != 1 || first.Password == "" {
+		t.Fatalf("unexpected result: %+v", first.Result)
+	}
+	user, err := staging.GetUser(ctx, first.TargetUserID)
+	if err != nil || user.Username == "alice" {
+		t.Fatalf("user not anonymized: %+v %v", user, err)
+	}
+
+	var second migrateResult
+	runJSON(t, &second, append(args, "--anonymize")...)
+	if second.UserCreated || second.Contacts.Unchanged != 1 || second.Notes.Unchanged != 1 {
+		t.Fatalf("re-run was not idempotent: %+v", second.Result)
+	}
+	if users, _ := staging.ListUsers(ctx); len(users) != 1 {
+		t.Fatalf("users were duplicated: %+v", users)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "same profile", args: []string{"migrate", "--from-profile", "prod", "--to-profile", "prod", "--user-id", alice.ID}, wantErr: "must be different"},
+		{name: "unknown profile", args: []string{"migrate", "--from-profile", "prod", "--to-profile", "qa", "--user-id", alice.ID}, wantErr: "profile 'qa' not found"},
+		{name: "unknown user", args: []string{"migrate", "--from-profile", "prod", "--to-profile", "staging", "--user-id", "missing"}, wantErr: "not found"},
+		{name: "missing flag", args: []string{"migrate", "--from-profile", "prod"}, wantErr: "not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := run(t, "", tt.args...); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrateAskPassword(t *testing.T) {
+	prod := setupFake(t)
+	staging := fake.New()
+	newClient = func() api.CRMService {
+		if config.ActiveProfileName() == "staging" {
+			return staging
+		}
+		return prod
+	}
+	mustRun(t, "profile", "add", "prod", "--base-url", "http://prod.test")
+	mustRun(t, "profile", "add", "staging", "--base-url", "http://staging.test")
+	alice, _ := prod.CreateUser(t.Context(), "alice", "secret")
+
+	stdout, err := run(t, "Welcome-1\n", "migrate", "--from-profile", "prod", "--to-profile", "staging", "--user-id", alice.ID, "--ask-password", "-o", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result migrateResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Password != "" || staging.Password(result.TargetUserID) != "Welcome-1" {
+		t.Fatalf("target user doesn't have the given password: %+v", result.Result)
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/fake"
+	"crm-admin/internal/mockserver"
+)
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run a local mock of the CRM backend",
+	Long: `Start a local HTTP server that implements every backend endpoint the CLI uses,
+for development and demos without the real backend.
+
+By default the server listens on localhost:8082 and serves the API under /api,
+matching the CLI's default base URL, so other crm-admin commands only need a
+token to work against it. Data is kept in memory unless --data is given, in
+which case it is loaded from and saved to that JSON file.
+
+Pass --token to require a bearer token on every request, as the real backend does.
+Without --token any token is accepted, but the CLI still needs one to be configured.
+Stop the server with Ctrl-C.`,
+	Example: `  crm-admin mock-server
+  CRM_ADMIN_API_KEY=dev crm-admin user list
+  crm-admin mock-server --addr :9090 --data ./mock-data.json --token dev-secret
+  CRM_ADMIN_API_KEY=dev-secret crm-admin --backend-url http://localhost:9090/api user list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		dataFile, _ := cmd.Flags().GetString("data")
+		token, _ := cmd.Flags().GetString("token")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+
+		options := mockserver.Options{
+			Prefix:   prefix,
+			Token:    token,
+			DataFile: dataFile,
+		}
+		if !quiet {
+			options.Log = os.Stderr
+		}
+
+		server, err := mockserver.New(fake.New(), options)
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+
+		httpServer := &http.Server{
+			Handler:           server.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Fprintf(os.Stderr, "🧪 Mock backend listening on http://%s%s\n", listener.Addr(), prefix)
+		if dataFile != "" {
+			fmt.Fprintf(os.Stderr, "   Data file: %s\n", dataFile)
+		} else {
+			fmt.Fprintln(os.Stderr, "   Data is kept in memory only")
+		}
+		if token != "" {
+			fmt.Fprintln(os.Stderr, "   Bearer token required")
+		}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- httpServer.Serve(listener)
+		}()
+
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("mock server failed: %w", err)
+		case <-cmd.Context().Done():
+		}
+
+		shutdownCtx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to stop mock server: %w", err)
+		}
+
+		fmt.Fprintln(os.Stderr, "👋 Mock backend stopped")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+
+	// Flags for mock-server
+	mockServerCmd.Flags().String("addr", "localhost:8082", "Address to listen on")
+	mockServerCmd.Flags().String("prefix", "/api", "Path the API is served under (the path of the base URL)")
+	mockServerCmd.Flags().String("data", "", "JSON file to load data from and save changes to (default: in memory only)")
+	mockServerCmd.Flags().String("token", "", "Require this bearer token on every request")
+	mockServerCmd.Flags().BoolP("quiet", "q", false, "Don't log requests")
+}
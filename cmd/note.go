@@ -2,15 +2,25 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/spf13/cobra"
 
-	"crm-admin/internal/api"
 	"crm-admin/internal/context"
 	"crm-admin/internal/models"
+	"crm-admin/internal/output"
 )
 
+// noteColumns are the columns available to 'note list'
+var noteColumns = []output.Column[models.Note]{
+	{Name: "id", Header: "ID", Numeric: true, Value: func(n models.Note) string { return strconv.Itoa(n.ID) }},
+	{Name: "title", Header: "Title", MaxWidth: 30, Value: func(n models.Note) string { return n.Title }},
+	{Name: "description", Header: "Description", MaxWidth: 50, Value: func(n models.Note) string { return stringValue(n.Description) }},
+	{Name: "contact-ids", Header: "Contact IDs", Value: func(n models.Note) string { return joinIDs(n.ContactIDs) }},
+	{Name: "user-id", Header: "User ID", Wide: true, Value: func(n models.Note) string { return n.UserID }},
+}
+
 var noteCmd = &cobra.Command{
 	Use:   "note",
 	Short: "Manage notes",
@@ -45,22 +55,23 @@ var noteCreateCmd = &cobra.Command{
 			contactIDs[i] = id
 		}
 
-		client := api.New()
+		client := newClient()
 
-		note, err := client.CreateNote(args[0], args[1], contactIDs, userID)
+		note, err := client.CreateNote(cmd.Context(), args[0], args[1], contactIDs, userID)
 		if err != nil {
 			return fmt.Errorf("failed to create note: %w", err)
 		}
 
-		fmt.Printf("✅ Note created successfully!\n")
-		fmt.Printf("   ID: %d\n", note.ID)
-		fmt.Printf("   Title: %s\n", note.Title)
-		if note.Description != nil {
-			fmt.Printf("   Description: %s\n", *note.Description)
-		}
-		fmt.Printf("   Contact IDs: %v\n", note.ContactIDs)
-		fmt.Printf("   User ID: %s\n", note.UserID)
-		return nil
+		return newPrinter().Print(note, func() {
+			fmt.Printf("✅ Note created successfully!\n")
+			fmt.Printf("   ID: %d\n", note.ID)
+			fmt.Printf("   Title: %s\n", note.Title)
+			if note.Description != nil {
+				fmt.Printf("   Description: %s\n", *note.Description)
+			}
+			fmt.Printf("   Contact IDs: %v\n", note.ContactIDs)
+			fmt.Printf("   User ID: %s\n", note.UserID)
+		})
 	},
 }
 
@@ -77,10 +88,15 @@ var noteListCmd = &cobra.Command{
 			return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]')")
 		}
 
-		client := api.New()
+		printer := newPrinter()
+		table, err := output.NewTablePrinter(noteColumns, tableOptions(cmd, printer))
+		if err != nil {
+			return err
+		}
+
+		client := newClient()
 
 		var notes []models.Note
-		var err error
 
 		// Show which user we're listing for
 		targetUserID := userID
@@ -94,65 +110,39 @@ var noteListCmd = &cobra.Command{
 		}
 
 		if contactID > 0 {
-			notes, err = client.ListNotesForContact(userID, contactID)
+			notes, err = client.ListNotesForContact(cmd.Context(), userID, contactID)
 			if err != nil {
 				return fmt.Errorf("failed to list notes for contact: %w", err)
 			}
 		} else {
-			notes, err = client.ListNotesForUser(userID)
+			notes, err = client.ListNotesForUser(cmd.Context(), userID)
 			if err != nil {
 				return fmt.Errorf("failed to list notes for user: %w", err)
 			}
 		}
-
-		if len(notes) == 0 {
-			if contactID > 0 {
-				fmt.Printf("No notes found for contact ID %d.\n", contactID)
-			} else if username != "" {
-				fmt.Printf("No notes found for %s (%s).\n", username, targetUserID)
-			} else {
-				fmt.Printf("No notes found for user %s.\n", targetUserID)
-			}
-			return nil
-		}
-
-		if contactID > 0 {
-			fmt.Printf("📝 Notes for Contact %d:\n", contactID)
-		} else if username != "" {
-			fmt.Printf("📝 Notes for %s (%s):\n", username, targetUserID)
-		} else {
-			fmt.Printf("📝 Notes for User %s:\n", targetUserID)
-		}
-
-		fmt.Printf("%-5s | %-25s | %-50s | %s\n",
-			"ID", "Title", "Description", "Contact IDs")
-		fmt.Printf("%-5s | %-25s | %-50s | %s\n",
-			"-----", "-------------------------", "--------------------------------------------------", "----------")
-
-		for _, note := range notes {
-			description := ""
-			if note.Description != nil {
-				desc := *note.Description
-				if len(desc) > 50 {
-					description = desc[:47] + "..."
+		table.Sort(notes)
+
+		return printer.Print(notes, func() {
+			if len(notes) == 0 {
+				if contactID > 0 {
+					fmt.Printf("No notes found for contact ID %d.\n", contactID)
+				} else if username != "" {
+					fmt.Printf("No notes found for %s (%s).\n", username, targetUserID)
 				} else {
-					description = desc
+					fmt.Printf("No notes found for user %s.\n", targetUserID)
 				}
+				return
 			}
 
-			contactIDs := ""
-			for i, id := range note.ContactIDs {
-				if i > 0 {
-					contactIDs += ","
-				}
-				contactIDs += fmt.Sprintf("%d", id)
+			if contactID > 0 {
+				fmt.Printf("📝 Notes for Contact %d:\n", contactID)
+			} else if username != "" {
+				fmt.Printf("📝 Notes for %s (%s):\n", username, targetUserID)
+			} else {
+				fmt.Printf("📝 Notes for User %s:\n", targetUserID)
 			}
-
-			fmt.Printf("%-5d | %-25s | %-50s | %s\n",
-				note.ID, note.Title, description, contactIDs)
-		}
-
-		return nil
+			table.Render(os.Stdout, notes)
+		})
 	},
 }
 
@@ -174,23 +164,23 @@ var noteGetCmd = &cobra.Command{
 			return fmt.Errorf("invalid note ID '%s': %w", args[0], err)
 		}
 
-		client := api.New()
+		client := newClient()
 
-		note, err := client.GetNote(userID, noteID)
+		note, err := client.GetNote(cmd.Context(), userID, noteID)
 		if err != nil {
 			return fmt.Errorf("failed to get note: %w", err)
 		}
 
-		fmt.Printf("📝 Note Details:\n")
-		fmt.Printf("   ID: %d\n", note.ID)
-		fmt.Printf("   Title: %s\n", note.Title)
-		if note.Description != nil {
-			fmt.Printf("   Description: %s\n", *note.Description)
-		}
-		fmt.Printf("   Contact IDs: %v\n", note.ContactIDs)
-		fmt.Printf("   User ID: %s\n", note.UserID)
-
-		return nil
+		return newPrinter().Print(note, func() {
+			fmt.Printf("📝 Note Details:\n")
+			fmt.Printf("   ID: %d\n", note.ID)
+			fmt.Printf("   Title: %s\n", note.Title)
+			if note.Description != nil {
+				fmt.Printf("   Description: %s\n", *note.Description)
+			}
+			fmt.Printf("   Contact IDs: %v\n", note.ContactIDs)
+			fmt.Printf("   User ID: %s\n", note.UserID)
+		})
 	},
 }
 
@@ -227,22 +217,22 @@ var noteUpdateCmd = &cobra.Command{
 			contactIDs[i] = id
 		}
 
-		client := api.New()
+		client := newClient()
 
-		note, err := client.UpdateNote(userID, noteID, args[1], args[2], contactIDs)
+		note, err := client.UpdateNote(cmd.Context(), userID, noteID, args[1], args[2], contactIDs)
 		if err != nil {
 			return fmt.Errorf("failed to update note: %w", err)
 		}
 
-		fmt.Printf("✅ Note updated successfully!\n")
-		fmt.Printf("   ID: %d\n", note.ID)
-		fmt.Printf("   Title: %s\n", note.Title)
-		if note.Description != nil {
-			fmt.Printf("   Description: %s\n", *note.Description)
-		}
-		fmt.Printf("   Contact IDs: %v\n", note.ContactIDs)
-
-		return nil
+		return newPrinter().Print(note, func() {
+			fmt.Printf("✅ Note updated successfully!\n")
+			fmt.Printf("   ID: %d\n", note.ID)
+			fmt.Printf("   Title: %s\n", note.Title)
+			if note.Description != nil {
+				fmt.Printf("   Description: %s\n", *note.Description)
+			}
+			fmt.Printf("   Contact IDs: %v\n", note.ContactIDs)
+		})
 	},
 }
 
@@ -264,16 +254,18 @@ var noteDeleteCmd = &cobra.Command{
 			return fmt.Errorf("invalid note ID '%s': %w", args[0], err)
 		}
 
-		client := api.New()
+		client := newClient()
 
-		err = client.DeleteNote(userID, noteID)
+		err = client.DeleteNote(cmd.Context(), userID, noteID)
 		if err != nil {
 			return fmt.Errorf("failed to delete note: %w", err)
 		}
 
-		fmt.Printf("✅ Note %d deleted successfully!\n", noteID)
+		result := deleteResult{Kind: "note", ID: noteID, Deleted: true}
 
-		return nil
+		return newPrinter().Print(result, func() {
+			fmt.Printf("✅ Note %d deleted successfully!\n", noteID)
+		})
 	},
 }
 
@@ -293,6 +285,7 @@ func init() {
 	// Flags for note list
 	noteListCmd.Flags().String("user-id", "", "ID of the user whose notes to list (optional if user is selected)")
 	noteListCmd.Flags().Int("contact-id", 0, "Filter notes by contact ID (optional)")
+	addTableFlags(noteListCmd)
 
 	// Flags for note get
 	noteGetCmd.Flags().String("user-id", "", "ID of the user who owns the note (optional if user is selected)")
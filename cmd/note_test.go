@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/models"
+)
+
+func TestNoteCommands(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	bob, _ := service.CreateUser(ctx, "bob", "secret")
+	jane, _ := service.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+	john, _ := service.CreateContact(ctx, "John", alice.ID, nil, nil, nil)
+	other, _ := service.CreateContact(ctx, "Other", bob.ID, nil, nil, nil)
+
+	mustRun(t, "user", "select", alice.ID)
+
+	var note models.Note
+	runJSON(t, &note, "note", "create", "Kickoff", "Project start", "--contact-ids", fmt.Sprintf("%d,%d", jane.ID, john.ID))
+	if note.UserID != alice.ID || !slices.Equal(note.ContactIDs, []int{jane.ID, john.ID}) {
+		t.Fatalf("unexpected note: %+v", note)
+	}
+	service.CreateNote(ctx, "Follow-up", "", []int{john.ID}, alice.ID)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantTitle []string
+		wantErr   string
+	}{
+		{name: "all notes", args: []string{"note", "list"}, wantTitle: []string{"Kickoff", "Follow-up"}},
+		{name: "notes for contact", args: []string{"note", "list", "--contact-id", strconv.Itoa(jane.ID)}, wantTitle: []string{"Kickoff"}},
+		{name: "foreign contact", args: []string{"note", "create", "Bad", "x", "--contact-ids", strconv.Itoa(other.ID)}, wantErr: "does not belong"},
+		{name: "missing contact IDs", args: []string{"note", "create", "Bad", "x"}, wantErr: `"contact-ids" not set`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr != "" {
+				_, err := run(t, "", tt.args...)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			var notes []models.Note
+			runJSON(t, &notes, tt.args...)
+			var titles []string
+			for _, note := range notes {
+				titles = append(titles, note.Title)
+			}
+			if !slices.Equal(titles, tt.wantTitle) {
+				t.Fatalf("titles = %v, want %v", titles, tt.wantTitle)
+			}
+		})
+	}
+
+	var fetched models.Note
+	runJSON(t, &fetched, "note", "get", strconv.Itoa(note.ID))
+	if fetched.Title != "Kickoff" || stringValue(fetched.Description) != "Project start" {
+		t.Fatalf("unexpected note: %+v", fetched)
+	}
+
+	var updated models.Note
+	runJSON(t, &updated, "note", "update", strconv.Itoa(note.ID), "Kickoff v2", "Moved", "--contact-ids", strconv.Itoa(jane.ID))
+	if updated.Title != "Kickoff v2" || !slices.Equal(updated.ContactIDs, []int{jane.ID}) {
+		t.Fatalf("unexpected note after update: %+v", updated)
+	}
+
+	// Deleting a contact unlinks it from its notes
+	mustRun(t, "contact", "delete", strconv.Itoa(jane.ID))
+	unlinked, err := service.GetNote(ctx, alice.ID, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unlinked.ContactIDs) != 0 {
+		t.Fatalf("contact IDs after delete = %v, want none", unlinked.ContactIDs)
+	}
+
+	mustRun(t, "note", "delete", strconv.Itoa(note.ID))
+	if _, err := run(t, "", "note", "get", strconv.Itoa(note.ID)); !api.IsNotFound(err) {
+		t.Fatalf("getting a deleted note: got %v, want not found", err)
+	}
+}
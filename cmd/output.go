@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/output"
+)
+
+// outputFormat and templateFile hold the values of the global --output and --template-file flags
+var (
+	outputFormat string
+	templateFile string
+)
+
+// deleteResult is the structured output of the delete commands
+type deleteResult struct {
+	Kind    string `json:"kind"`
+	ID      any    `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// newPrinter returns a printer for the format selected with --output.
+// The flags are validated by validateOutputFlags before any command runs.
+func newPrinter() *output.Printer {
+	printer, err := buildPrinter()
+	if err != nil {
+		return output.New(output.Table, os.Stdout, os.Stderr)
+	}
+	return printer
+}
+
+// validateOutputFlags checks --output and --template-file, including the template syntax
+func validateOutputFlags() error {
+	_, err := buildPrinter()
+	return err
+}
+
+func buildPrinter() (*output.Printer, error) {
+	format, template, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	if templateFile != "" {
+		// --template-file on its own implies a Go template
+		if format == output.Table {
+			format = output.GoTemplate
+		}
+		if !format.IsTemplate() {
+			return nil, fmt.Errorf("--template-file can only be used with -o go-template or -o jsonpath")
+		}
+		if template != "" {
+			return nil, fmt.Errorf("use either an inline template or --template-file, not both")
+		}
+
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		template = string(data)
+	}
+
+	if format.IsTemplate() {
+		return output.NewTemplate(format, template, os.Stdout, os.Stderr)
+	}
+	return output.New(format, os.Stdout, os.Stderr), nil
+}
+
+// addTableFlags registers the flags that control table rendering on a list command
+func addTableFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("columns", []string{}, "Comma-separated list of columns to show (e.g. id,name,email)")
+	cmd.Flags().String("sort-by", "", "Column to sort by; prefix with '-' for descending order (e.g. -id)")
+	cmd.Flags().Bool("no-headers", false, "Don't print the header rows")
+	cmd.Flags().Bool("wide", false, "Show all columns without truncation (same as -o wide)")
+}
+
+// tableOptions reads the table flags registered by addTableFlags
+func tableOptions(cmd *cobra.Command, printer *output.Printer) output.TableOptions {
+	columns, _ := cmd.Flags().GetStringSlice("columns")
+	sortBy, _ := cmd.Flags().GetString("sort-by")
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+	wide, _ := cmd.Flags().GetBool("wide")
+
+	return output.TableOptions{
+		Columns:   columns,
+		SortBy:    sortBy,
+		NoHeaders: noHeaders,
+		Wide:      wide || printer.IsWide(),
+		Width:     output.TerminalWidth(),
+	}
+}
+
+// stringValue returns the value of an optional field, or an empty string when it is unset
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// joinIDs formats a list of IDs as a comma-separated string
+func joinIDs(ids []int) string {
+	result := ""
+	for i, id := range ids {
+		if i > 0 {
+			result += ","
+		}
+		result += strconv.Itoa(id)
+	}
+	return result
+}
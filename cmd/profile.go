@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"crm-admin/internal/config"
+	"crm-admin/internal/credentials"
+	"crm-admin/internal/output"
+)
+
+// profileInfo is the structured output of 'profile list'
+type profileInfo struct {
+	Name        string `json:"name"`
+	Current     bool   `json:"current"`
+	BaseURL     string `json:"baseUrl,omitempty"`
+	TokenSource string `json:"tokenSource,omitempty"`
+	DefaultUser string `json:"defaultUser,omitempty"`
+}
+
+var profileColumns = []output.Column[profileInfo]{
+	{Name: "current", Header: " ", Value: func(p profileInfo) string {
+		if p.Current {
+			return "*"
+		}
+		return ""
+	}},
+	{Name: "name", Header: "Name", Value: func(p profileInfo) string { return p.Name }},
+	{Name: "base-url", Header: "Base URL", Value: func(p profileInfo) string { return p.BaseURL }},
+	{Name: "token", Header: "Token Source", Value: func(p profileInfo) string { return p.TokenSource }},
+	{Name: "default-user", Header: "Default User", Value: func(p profileInfo) string { return p.DefaultUser }},
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage backend profiles",
+	Long: `Manage named profiles for the backends you work with (for example dev, staging and prod).
+
+Profiles are stored in the config file (default $XDG_CONFIG_HOME/crm-admin/config.yaml).
+Each profile holds a base URL, where to find the admin token and an optional default user.
+
+Settings are resolved in this order: command line flag, environment variable,
+active profile, built-in default. The active profile is chosen with --profile,
+then $CRM_PROFILE, then 'crm-admin profile use'.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Long:  `Display all configured profiles. The active profile is marked with '*'.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := config.ReadFile()
+		if err != nil {
+			return err
+		}
+
+		active := config.ActiveProfileName()
+		profiles := make([]profileInfo, 0, len(file.Profiles))
+		for _, name := range file.ProfileNames() {
+			profile := file.Profiles[name]
+			profiles = append(profiles, profileInfo{
+				Name:        name,
+				Current:     name == active,
+				BaseURL:     profile.BaseURL,
+				TokenSource: tokenSource(name, profile),
+				DefaultUser: profile.DefaultUser,
+			})
+		}
+
+		printer := newPrinter()
+		table, err := output.NewTablePrinter(profileColumns, output.TableOptions{Wide: printer.IsWide()})
+		if err != nil {
+			return err
+		}
+
+		return printer.Print(profiles, func() {
+			if len(profiles) == 0 {
+				fmt.Printf("No profiles found in %s.\n", config.Path())
+				fmt.Printf("Create one with: crm-admin profile add [name] --base-url [url]\n")
+				return
+			}
+
+			fmt.Printf("⚙️  Profiles (%s):\n", config.Path())
+			table.Render(os.Stdout, profiles)
+		})
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Switch the active profile",
+	Long:  `Make the named profile the active profile for subsequent commands.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		file, err := config.ReadFile()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := file.Profiles[name]; !ok {
+			return fmt.Errorf("profile '%s' not found (see 'crm-admin profile list')", name)
+		}
+
+		file.CurrentProfile = name
+		if err := file.Save(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(newPrinter().Messages(), "✅ Switched to profile '%s'\n", name)
+		return nil
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add or update a profile",
+	Long: `Add a new profile, or update the settings of an existing one.
+
+The admin token can be read from an environment variable (--token-env) or
+from a file (--token-file). Storing the token itself in the config file with
+--token is supported but not recommended.`,
+	Example: `  crm-admin profile add staging --base-url https://staging.example.com --token-env STAGING_ADMIN_KEY
+  crm-admin profile add prod --base-url https://crm.example.com --token-file ~/.crm-prod-token --use`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		flags := cmd.Flags()
+
+		file, err := config.ReadFile()
+		if err != nil {
+			return err
+		}
+		if file.Profiles == nil {
+			file.Profiles = make(map[string]*config.Profile)
+		}
+
+		profile, exists := file.Profiles[name]
+		if !exists {
+			profile = &config.Profile{}
+			file.Profiles[name] = profile
+		}
+
+		if flags.Changed("base-url") {
+			profile.BaseURL, _ = flags.GetString("base-url")
+		}
+		if flags.Changed("token") {
+			profile.Token, _ = flags.GetString("token")
+		}
+		if flags.Changed("token-env") {
+			profile.TokenEnv, _ = flags.GetString("token-env")
+		}
+		if flags.Changed("token-file") {
+			profile.TokenFile, _ = flags.GetString("token-file")
+		}
+		if flags.Changed("default-user") {
+			profile.DefaultUser, _ = flags.GetString("default-user")
+		}
+		if flags.Changed("login-endpoint") {
+			profile.LoginEndpoint, _ = flags.GetString("login-endpoint")
+		}
+		if flags.Changed("refresh-endpoint") {
+			profile.RefreshEndpoint, _ = flags.GetString("refresh-endpoint")
+		}
+
+		use, _ := flags.GetBool("use")
+		if use || len(file.Profiles) == 1 {
+			file.CurrentProfile = name
+		}
+
+		if err := file.Save(); err != nil {
+			return err
+		}
+
+		messages := newPrinter().Messages()
+		if exists {
+			fmt.Fprintf(messages, "✅ Profile '%s' updated\n", name)
+		} else {
+			fmt.Fprintf(messages, "✅ Profile '%s' added\n", name)
+		}
+		if file.CurrentProfile == name {
+			fmt.Fprintf(messages, "🎯 '%s' is now the active profile\n", name)
+		}
+		return nil
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Remove a profile",
+	Long:  `Remove a profile from the config file.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		file, err := config.ReadFile()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := file.Profiles[name]; !ok {
+			return fmt.Errorf("profile '%s' not found (see 'crm-admin profile list')", name)
+		}
+
+		delete(file.Profiles, name)
+		if file.CurrentProfile == name {
+			file.CurrentProfile = ""
+		}
+
+		if err := file.Save(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(newPrinter().Messages(), "✅ Profile '%s' removed\n", name)
+		return nil
+	},
+}
+
+// tokenSource describes where a profile gets its admin token from, without revealing it
+func tokenSource(name string, profile *config.Profile) string {
+	switch {
+	case profile.TokenEnv != "":
+		return "env:" + profile.TokenEnv
+	case profile.TokenFile != "":
+		return "file:" + profile.TokenFile
+	case profile.Token != "":
+		return "config"
+	case credentials.StoredKeyring(name) != "":
+		return "login:" + string(credentials.StoredKeyring(name))
+	default:
+		return ""
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+
+	// Flags for profile add
+	profileAddCmd.Flags().String("base-url", "", "Backend URL for this profile")
+	profileAddCmd.Flags().String("token", "", "Admin token to store in the config file (prefer --token-env or --token-file)")
+	profileAddCmd.Flags().String("token-env", "", "Environment variable that holds the admin token")
+	profileAddCmd.Flags().String("token-file", "", "File that holds the admin token")
+	profileAddCmd.Flags().String("default-user", "", "User ID to use when no user is selected")
+	profileAddCmd.Flags().String("login-endpoint", "", "Endpoint that exchanges a username and password for a token in 'auth login'")
+	profileAddCmd.Flags().String("refresh-endpoint", "", "Endpoint that exchanges the refresh token from 'auth login' for a new token on 401")
+	profileAddCmd.Flags().Bool("use", false, "Make this the active profile")
+}
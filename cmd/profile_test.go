@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfileCommands(t *testing.T) {
+	setupFake(t)
+
+	steps := []struct {
+		name        string
+		args        []string
+		wantErr     string
+		wantCurrent string
+		wantNames   []string
+	}{
+		{name: "first profile becomes current", args: []string{"profile", "add", "dev", "--base-url", "http://dev.test"}, wantCurrent: "dev", wantNames: []string{"dev"}},
+		{name: "add second", args: []string{"profile", "add", "prod", "--token-env", "PROD_KEY"}, wantCurrent: "dev", wantNames: []string{"dev", "prod"}},
+		{name: "switch", args: []string{"profile", "use", "prod"}, wantCurrent: "prod", wantNames: []string{"dev", "prod"}},
+		{name: "use unknown", args: []string{"profile", "use", "staging"}, wantErr: "not found"},
+		{name: "remove current", args: []string{"profile", "remove", "prod"}, wantCurrent: "", wantNames: []string{"dev"}},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			_, err := run(t, "", step.args...)
+			if step.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), step.wantErr) {
+					t.Fatalf("got error %v, want %q", err, step.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var profiles []profileInfo
+			runJSON(t, &profiles, "profile", "list")
+
+			var names []string
+			current := ""
+			for _, profile := range profiles {
+				names = append(names, profile.Name)
+				if profile.Current {
+					current = profile.Name
+				}
+			}
+			if !slices.Equal(names, step.wantNames) || current != step.wantCurrent {
+				t.Fatalf("profiles = %v (current %q), want %v (current %q)", names, current, step.wantNames, step.wantCurrent)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// readPassword reads a password from the terminal without echoing it, asking twice
+// so typos are caught. When stdin is not a terminal the first line of stdin is used.
+func readPassword(prompt string) (string, error) {
+	first, err := readSecret(prompt, "password")
+	if err != nil || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return first, err
+	}
+
+	second, err := readSecret("Confirm password: ", "password")
+	if err != nil {
+		return "", err
+	}
+	if first != second {
+		return "", fmt.Errorf("passwords do not match")
+	}
+
+	return first, nil
+}
+
+// readSecret reads a secret such as a password or key from the terminal without
+// echoing it. When stdin is not a terminal the first line of stdin is used.
+// what names the secret in error messages.
+func readSecret(prompt, what string) (string, error) {
+	fd := int(os.Stdin.Fd())
+
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read %s from stdin: %w", what, err)
+		}
+		secret := strings.TrimRight(line, "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("no %s provided on stdin", what)
+		}
+		return secret, nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	if len(secret) == 0 {
+		return "", fmt.Errorf("%s cannot be empty", what)
+	}
+
+	return string(secret), nil
+}
+
+// confirm asks a yes/no question on the terminal and reports whether the answer was yes.
+// Without a terminal there is nobody to ask, so it returns false.
+func confirm(prompt string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -1,10 +1,19 @@
 package cmd
 
 import (
+	stdcontext "context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"crm-admin/internal/api"
+	"crm-admin/internal/config"
 	"crm-admin/internal/context"
+	"crm-admin/internal/credentials"
 
 	"github.com/spf13/cobra"
 )
@@ -31,17 +40,156 @@ Examples:
   
   # Note management (with user selected)
   crm-admin note create "Meeting Notes" "Discussed project timeline" --contact-ids 1,2
-  crm-admin note list`,
+  crm-admin note list
+
+  # Log in and store the admin key encrypted for the active profile
+  crm-admin auth login
+  crm-admin auth status
+
+  # Profiles for several backends
+  crm-admin profile add staging --base-url https://staging.example.com --token-env STAGING_ADMIN_KEY
+  crm-admin profile use staging
+  crm-admin --profile prod user list
+
+  # Machine-readable output
+  crm-admin contact list -o json
+  crm-admin user list --output yaml
+  crm-admin contact list -o go-template='{{range .}}{{.ID}} {{.Name}}{{"\n"}}{{end}}'
+  crm-admin contact list -o jsonpath='{.[*].contactEmail}'
+
+  # Record backend traffic and replay it offline (credentials and cookies are redacted)
+  crm-admin --record ./cassettes/list contact list
+  crm-admin --replay ./cassettes/list contact list
+
+  # Trace HTTP traffic to stderr (tokens, passwords and cookies are masked)
+  crm-admin -v contact list
+  crm-admin --debug --log-format json user create alice secret
+
+Exit codes:
+  0  success
+  1  general error
+  3  not found (404, or no user matches a reference)
+  4  authentication failed (401/403) or no credential configured
+  5  conflict (409)
+  6  backend error (5xx)
+  130  interrupted`,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Arguments and flags have been parsed; later errors aren't usage mistakes
+		cmd.SilenceUsage = true
+
+		if err := loadConfig(cmd); err != nil {
+			return err
+		}
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		return validateOutputFlags()
+	},
+}
+
+// Process exit codes, so scripts can tell failures apart without parsing messages
+const (
+	exitError       = 1   // any other failure
+	exitNotFound    = 3   // the backend returned 404, or a lookup found nothing
+	exitAuth        = 4   // the backend rejected the credentials (401/403)
+	exitConflict    = 5   // the backend returned 409
+	exitServer      = 6   // the backend failed (5xx)
+	exitInterrupted = 130 // cancelled with Ctrl-C
+)
+
+// notFoundError reports that something doesn't exist when the backend didn't say
+// so with a 404, such as a user reference that matches no one
+type notFoundError struct {
+	error
+}
+
+// exitCode maps an error returned by a command to the process exit code;
+// interrupted tells whether the command was cancelled with Ctrl-C
+func exitCode(err error, interrupted bool) int {
+	switch {
+	case interrupted && errors.Is(err, stdcontext.Canceled):
+		return exitInterrupted
+	case api.IsNotFound(err), errors.As(err, new(notFoundError)):
+		return exitNotFound
+	case api.IsUnauthorized(err), errors.Is(err, credentials.ErrNoCredential):
+		return exitAuth
+	case api.IsConflict(err):
+		return exitConflict
+	case api.IsServerError(err):
+		return exitServer
+	default:
+		return exitError
+	}
+}
+
+// Global flags that configure where the CLI connects to
+var (
+	cfgFile         string
+	profile         string
+	backendURL      string
+	retries         int
+	timeout         time.Duration
+	deadline        time.Duration
+	idempotencyKeys bool
+	recordDir       string
+	replayDir       string
+)
+
+// Global flags that control diagnostic logging
+var (
+	verbose   bool
+	debug     bool
+	logFormat string
+)
+
+// traceLogger logs HTTP traffic to stderr; it is nil unless --verbose or --debug is given
+var traceLogger *slog.Logger
+
+// newClient creates the backend client used by the commands; tests replace it with a fake
+var newClient = func() api.CRMService {
+	return newAPIClient()
+}
+
+// newAPIClient creates a client for the backend, for operations outside CRMService
+func newAPIClient() *api.Client {
+	warnTokenExpiry()
+
+	client := api.New()
+	if traceLogger != nil {
+		client.Use(api.TraceMiddleware(traceLogger))
+	}
+	return client
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
+	// Load the default config file so the prompt reflects the active profile;
+	// errors are reported once flags have been parsed
+	_ = config.Load()
+
 	// Update command prompt based on user context
 	updatePromptForContext()
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	// Ctrl-C cancels in-flight requests through the command's context; a second
+	// Ctrl-C falls back to the default behaviour and terminates immediately
+	ctx, stop := signal.NotifyContext(stdcontext.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
+		code := exitCode(err, ctx.Err() != nil)
+		if code == exitInterrupted {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+		} else {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(code)
 	}
 }
 
@@ -54,7 +202,83 @@ func updatePromptForContext() {
 	}
 }
 
+// loadConfig applies the global config flags and reads the config file
+func loadConfig(cmd *cobra.Command) error {
+	config.SetPath(cfgFile)
+	config.SetProfile(profile)
+	config.SetBaseURL(backendURL)
+	config.SetIdempotencyKeys(idempotencyKeys)
+	config.SetRecordDir(recordDir)
+	config.SetReplayDir(replayDir)
+
+	// Only override the environment when the flags were given explicitly
+	flags := cmd.Flags()
+	if flags.Changed("retries") {
+		if retries < 0 {
+			return fmt.Errorf("--retries must not be negative")
+		}
+		config.SetRetries(retries)
+	}
+	if flags.Changed("timeout") {
+		config.SetTimeout(timeout)
+	}
+	if flags.Changed("deadline") {
+		config.SetDeadline(deadline)
+	}
+
+	if err := config.Load(); err != nil {
+		return err
+	}
+	if config.GetRecordDir() != "" && config.GetReplayDir() != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+
+	// The profile commands must keep working when the selected profile doesn't exist yet
+	if cmd.Parent() == profileCmd {
+		return nil
+	}
+	return config.CheckActiveProfile()
+}
+
+// setupLogging creates the trace logger for --verbose and --debug. Logs always go
+// to stderr so they never mix with command output.
+func setupLogging() error {
+	traceLogger = nil
+
+	var handler slog.Handler
+	options := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if debug {
+		options.Level = slog.LevelDebug
+	}
+	switch logFormat {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("invalid --log-format '%s' (use text or json)", logFormat)
+	}
+
+	if verbose || debug {
+		traceLogger = slog.New(handler)
+	}
+	return nil
+}
+
 func init() {
 	// Global flags can be added here
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.crm-admin.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/crm-admin/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "profile to use from the config file (overrides $CRM_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&backendURL, "backend-url", "", "backend URL (overrides $CRM_BACKEND_URL and the profile)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", config.DefaultRetries, "retries for failed GET/PUT/DELETE requests on connection errors, 429 and 5xx ($CRM_RETRIES)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", config.DefaultTimeout, "timeout for a single HTTP attempt ($CRM_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&deadline, "deadline", 0, "total time allowed per request including retries, 0 for no limit ($CRM_DEADLINE)")
+	rootCmd.PersistentFlags().BoolVar(&idempotencyKeys, "idempotency-keys", false, "send an Idempotency-Key with POST requests so they can be retried safely")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "record every HTTP request and response to cassette files in this directory ($CRM_RECORD)")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "answer HTTP requests from cassette files in this directory instead of the network ($CRM_REPLAY)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log the method, URL, status and latency of every HTTP request to stderr")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "like --verbose, and also log request and response headers and bodies (secrets are masked)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "format of --verbose and --debug logs: text or json")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table, wide, json, yaml, ndjson, go-template=... or jsonpath=...")
+	rootCmd.PersistentFlags().StringVar(&templateFile, "template-file", "", "Read the go-template or jsonpath template from a file")
 }
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/credentials"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		interrupted bool
+		want        int
+	}{
+		{name: "not found", err: api.NewError(http.StatusNotFound, "user not found"), want: exitNotFound},
+		{name: "wrapped not found", err: fmt.Errorf("failed to get contact: %w", api.NewError(http.StatusNotFound, "")), want: exitNotFound},
+		{name: "unauthorized", err: api.NewError(http.StatusUnauthorized, ""), want: exitAuth},
+		{name: "forbidden", err: api.NewError(http.StatusForbidden, ""), want: exitAuth},
+		{name: "no credential", err: fmt.Errorf("request failed: %w", credentials.ErrNoCredential), want: exitAuth},
+		{name: "conflict", err: api.NewError(http.StatusConflict, ""), want: exitConflict},
+		{name: "server error", err: api.NewError(http.StatusInternalServerError, ""), want: exitServer},
+		{name: "bad gateway", err: api.NewError(http.StatusBadGateway, ""), want: exitServer},
+		{name: "bad request", err: api.NewError(http.StatusBadRequest, ""), want: exitError},
+		{name: "lookup found nothing", err: fmt.Errorf("failed to select user: %w", notFoundError{errors.New("no user found")}), want: exitNotFound},
+		{name: "plain error", err: errors.New("invalid contact ID"), want: exitError},
+		{name: "interrupted", err: fmt.Errorf("failed to list users: %w", stdcontext.Canceled), interrupted: true, want: exitInterrupted},
+		{name: "cancelled without interrupt", err: stdcontext.Canceled, want: exitError},
+		{name: "interrupted with another error", err: api.NewError(http.StatusNotFound, ""), interrupted: true, want: exitNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err, tt.interrupted); got != tt.want {
+				t.Fatalf("exitCode(%v, %v) = %d, want %d", tt.err, tt.interrupted, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"crm-admin/internal/context"
+	"crm-admin/internal/export"
+	"crm-admin/internal/search"
+)
+
+// ANSI escapes that highlight matches on a terminal
+const (
+	highlightStart = "\033[1;33m"
+	highlightEnd   = "\033[0m"
+)
+
+// searchResult is the structured output of 'search'
+type searchResult struct {
+	Query string `json:"query"`
+	search.Results
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query...]",
+	Short: "Search contacts and notes",
+	Long: `Search the contacts and notes of the selected user, or of every user with
+--all-users. The data is fetched and searched locally.
+
+A query is made of terms separated by spaces, and a result has to match every term.
+A plain term is searched in every field: the name, company, phone number and email
+of contacts, and the title and description of notes. Prefix a term with a field to
+only search that field:
+
+  company:acme            contacts whose company contains "acme"
+  email:*@gmail.com       * and ? are wildcards that must match the whole field
+  title:"follow up"       double quotes keep spaces in a term
+  type:note               only return notes (or type:contact)
+
+Matching ignores case unless --case-sensitive is given. With --regex, values are
+regular expressions (Go syntax) that can match anywhere in a field. Results are
+grouped by type, with the matched parts highlighted on a terminal.`,
+	Example: `  crm-admin search acme
+  crm-admin search 'company:acme email:*@gmail.com'
+  crm-admin search --all-users --regex 'phone:^\+44'
+  crm-admin search 'type:note title:"follow up"' -o json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID, _ := cmd.Flags().GetString("user-id")
+		allUsers, _ := cmd.Flags().GetBool("all-users")
+		regex, _ := cmd.Flags().GetBool("regex")
+		caseSensitive, _ := cmd.Flags().GetBool("case-sensitive")
+		highlight, _ := cmd.Flags().GetString("highlight")
+
+		if allUsers && userID != "" {
+			return fmt.Errorf("pass either --user-id or --all-users")
+		}
+		// Check if user-id is provided or if we have context
+		if userID == "" && !allUsers {
+			userContext, _ := context.LoadUserContext()
+			if userContext == nil {
+				return fmt.Errorf("user-id flag is required (or select a user with 'crm-admin user select [user-id]', or use --all-users)")
+			}
+			userID = userContext.UserID
+		}
+
+		var start, end string
+		switch highlight {
+		case "always":
+			start, end = highlightStart, highlightEnd
+		case "auto":
+			if term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == "" {
+				start, end = highlightStart, highlightEnd
+			}
+		case "never":
+		default:
+			return fmt.Errorf("invalid --highlight value '%s' (use auto, always or never)", highlight)
+		}
+
+		queryText := strings.Join(args, " ")
+		query, err := search.Parse(queryText, search.Options{Regex: regex, CaseSensitive: caseSensitive})
+		if err != nil {
+			return err
+		}
+
+		client := newClient()
+
+		var datasets []*export.Dataset
+		if allUsers {
+			users, err := client.ListUsers(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list users: %w", err)
+			}
+			for _, user := range users {
+				dataset, err := export.FetchFor(cmd.Context(), client, user)
+				if err != nil {
+					return err
+				}
+				datasets = append(datasets, dataset)
+			}
+		} else {
+			dataset, err := export.Fetch(cmd.Context(), client, userID)
+			if err != nil {
+				return err
+			}
+			datasets = append(datasets, dataset)
+		}
+
+		output := searchResult{Query: queryText, Results: search.Results{Contacts: []search.Hit{}, Notes: []search.Hit{}}}
+		for _, dataset := range datasets {
+			results := query.Search(dataset)
+			output.Contacts = append(output.Contacts, results.Contacts...)
+			output.Notes = append(output.Notes, results.Notes...)
+		}
+
+		return newPrinter().Print(output, func() {
+			if len(output.Contacts) == 0 && len(output.Notes) == 0 {
+				fmt.Printf("No contacts or notes match '%s'.\n", queryText)
+				return
+			}
+
+			fmt.Printf("🔍 %d contact(s) and %d note(s) match '%s'\n", len(output.Contacts), len(output.Notes), queryText)
+			printHits := func(title string, hits []search.Hit) {
+				if len(hits) == 0 {
+					return
+				}
+				fmt.Printf("\n%s:\n", title)
+				for _, hit := range hits {
+					owner := ""
+					if allUsers {
+						owner = fmt.Sprintf(" (%s)", hit.Username)
+					}
+					fmt.Printf("   %d  %s%s\n", hit.ID, hit.Label, owner)
+					for _, match := range hit.Matches {
+						fmt.Printf("       %s: %s\n", match.Field, match.Highlight(start, end))
+					}
+				}
+			}
+			printHits("📇 Contacts", output.Contacts)
+			printHits("📝 Notes", output.Notes)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	// Flags for search
+	searchCmd.Flags().String("user-id", "", "ID of the user whose contacts and notes to search (optional if user is selected)")
+	searchCmd.Flags().Bool("all-users", false, "Search the contacts and notes of every user")
+	searchCmd.Flags().Bool("regex", false, "Treat values as regular expressions")
+	searchCmd.Flags().Bool("case-sensitive", false, "Match upper and lower case exactly")
+	searchCmd.Flags().String("highlight", "auto", "Highlight matches: auto (on a terminal), always or never")
+}
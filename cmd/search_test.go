@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	bob, _ := service.CreateUser(ctx, "bob", "secret")
+	acme := "Acme"
+	service.CreateContact(ctx, "Jane", alice.ID, &acme, nil, nil)
+	service.CreateContact(ctx, "Acme Buyer", bob.ID, nil, nil, nil)
+	service.CreateNote(ctx, "Call acme", "", nil, alice.ID)
+
+	tests := []struct {
+		name         string
+		args         []string
+		selectFirst  bool
+		wantContacts int
+		wantNotes    int
+		wantErr      string
+	}{
+		{name: "selected user", args: []string{"acme"}, selectFirst: true, wantContacts: 1, wantNotes: 1},
+		{name: "user ID", args: []string{"company:acme", "--user-id", alice.ID}, wantContacts: 1},
+		{name: "all users", args: []string{"acme", "--all-users"}, wantContacts: 2, wantNotes: 1},
+		{name: "no user", args: []string{"acme"}, wantErr: "user-id flag is required"},
+		{name: "user and all users", args: []string{"acme", "--all-users", "--user-id", alice.ID}, wantErr: "either --user-id or --all-users"},
+		{name: "bad query", args: []string{"city:x", "--all-users"}, wantErr: "unknown field"},
+		{name: "bad highlight", args: []string{"acme", "--all-users", "--highlight", "sometimes"}, wantErr: "invalid --highlight"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.selectFirst {
+				mustRun(t, "user", "select", alice.ID)
+				defer mustRun(t, "user", "exit")
+			}
+
+			if tt.wantErr != "" {
+				_, err := run(t, "", append([]string{"search"}, tt.args...)...)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			var result searchResult
+			runJSON(t, &result, append([]string{"search"}, tt.args...)...)
+			if len(result.Contacts) != tt.wantContacts || len(result.Notes) != tt.wantNotes {
+				t.Fatalf("got %d contacts and %d notes, want %d and %d", len(result.Contacts), len(result.Notes), tt.wantContacts, tt.wantNotes)
+			}
+		})
+	}
+
+	stdout := mustRun(t, "search", "acme", "--all-users", "--highlight", "always")
+	if !strings.Contains(stdout, "name: "+highlightStart+"Acme"+highlightEnd+" Buyer") {
+		t.Fatalf("match not highlighted:\n%s", stdout)
+	}
+}
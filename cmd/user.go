@@ -1,15 +1,38 @@
 package cmd
 
 import (
+	stdcontext "context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"crm-admin/internal/api"
+	"crm-admin/internal/config"
 	"crm-admin/internal/context"
 	"crm-admin/internal/models"
 )
 
+// userInfo is the structured output of 'user info'
+type userInfo struct {
+	User     models.User      `json:"user"`
+	Contacts []models.Contact `json:"contacts"`
+	Notes    []models.Note    `json:"notes"`
+}
+
+// userDeleteResult is the structured output of 'user delete', including what was cascaded
+type userDeleteResult struct {
+	deleteResult
+	Contacts int `json:"contacts"`
+	Notes    int `json:"notes"`
+}
+
+// passwordResult is the structured output of 'user set-password'
+type passwordResult struct {
+	ID              string `json:"id"`
+	PasswordUpdated bool   `json:"passwordUpdated"`
+}
+
 var userCmd = &cobra.Command{
 	Use:   "user",
 	Short: "Manage users",
@@ -22,18 +45,19 @@ var userCreateCmd = &cobra.Command{
 	Long:  `Create a new user with the specified username and password.`,
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := api.New()
+		client := newClient()
 
-		user, err := client.CreateUser(args[0], args[1])
+		user, err := client.CreateUser(cmd.Context(), args[0], args[1])
 		if err != nil {
 			return fmt.Errorf("failed to create user: %w", err)
 		}
 
-		fmt.Printf("✅ User '%s' created successfully!\n", user.Username)
-		fmt.Printf("   ID: %s\n", user.ID)
-		fmt.Printf("\nYou can now select this user to work with their data:\n")
-		fmt.Printf("   crm-admin user select %s\n", user.ID)
-		return nil
+		return newPrinter().Print(user, func() {
+			fmt.Printf("✅ User '%s' created successfully!\n", user.Username)
+			fmt.Printf("   ID: %s\n", user.ID)
+			fmt.Printf("\nYou can now select this user to work with their data:\n")
+			fmt.Printf("   crm-admin user select %s\n", user.ID)
+		})
 	},
 }
 
@@ -42,82 +66,103 @@ var userListCmd = &cobra.Command{
 	Short: "List all users",
 	Long:  `Display a list of all users in the CRM system.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := api.New()
+		client := newClient()
 
-		users, err := client.ListUsers()
+		users, err := client.ListUsers(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("failed to list users: %w", err)
 		}
 
-		if len(users) == 0 {
-			fmt.Println("No users found.")
-			return nil
-		}
+		return newPrinter().Print(users, func() {
+			if len(users) == 0 {
+				fmt.Println("No users found.")
+				return
+			}
 
-		// Show current context if any
-		if context.HasUserContext() {
+			// Show current context if any
 			userContext, _ := context.LoadUserContext()
 			if userContext != nil {
 				fmt.Printf("📌 Currently selected: %s (%s)\n\n", userContext.Username, userContext.UserID)
 			}
-		}
 
-		fmt.Println("📋 Users:")
-		fmt.Printf("%-36s | %s\n", "ID", "Username")
-		fmt.Printf("%-36s | %s\n", "------------------------------------", "--------")
+			fmt.Println("📋 Users:")
+			fmt.Printf("%-36s | %s\n", "ID", "Username")
+			fmt.Printf("%-36s | %s\n", "------------------------------------", "--------")
 
-		for _, user := range users {
-			marker := ""
-			if context.HasUserContext() {
-				userContext, _ := context.LoadUserContext()
+			for _, user := range users {
+				marker := ""
 				if userContext != nil && userContext.UserID == user.ID {
 					marker = " ← SELECTED"
 				}
+				fmt.Printf("%-36s | %s%s\n", user.ID, user.Username, marker)
 			}
-			fmt.Printf("%-36s | %s%s\n", user.ID, user.Username, marker)
-		}
-
-		fmt.Printf("\nTo select a user for easier management:\n")
-		fmt.Printf("   crm-admin user select [user-id]\n")
 
-		return nil
+			fmt.Printf("\nTo select a user for easier management:\n")
+			fmt.Printf("   crm-admin user select [user-id]\n")
+		})
 	},
 }
 
 var userSelectCmd = &cobra.Command{
-	Use:   "select [user-id]",
+	Use:   "select [user]",
 	Short: "Select a user to work with",
-	Long:  `Select a user by ID. This will make all subsequent contact and note operations default to this user.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Select a user by ID, username or a unique prefix of the ID. This will make all
+subsequent contact and note operations default to this user.
+
+The user is looked up on the backend first so the real username is stored.
+Pass --no-verify to skip the lookup when working offline; the argument is then
+used as the user ID as-is.
+
+The selection is remembered per profile, independent of the current directory.
+Use --local to select a user for the current directory only; a local selection
+takes precedence over the profile's selection while you work in that directory.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		userID := args[0]
+		noVerify, _ := cmd.Flags().GetBool("no-verify")
 
-		// Create a user object with just the ID for context
-		// We don't need to verify it exists - just set the context
 		user := &models.User{
-			ID:       userID,
-			Username: userID, // Use ID as username for display until we can get the real username
+			ID:       args[0],
+			Username: args[0], // Use ID as username for display when the user isn't looked up
 		}
 
+		if !noVerify {
+			resolved, err := resolveUser(cmd.Context(), newClient(), args[0])
+			if err != nil {
+				return err
+			}
+			user = resolved
+		}
+		userID := user.ID
+
 		// Save user context
-		err := context.SaveUserContext(user)
+		local, _ := cmd.Flags().GetBool("local")
+		var err error
+		if local {
+			err = context.SaveLocalUserContext(user)
+		} else {
+			err = context.SaveUserContext(user)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to save user context: %w", err)
 		}
 
-		fmt.Printf("✅ Selected user ID: %s\n", userID)
-		fmt.Printf("🎯 Context set - commands will now default to this user\n")
-		fmt.Println()
-		fmt.Println("You can now run commands without specifying --user-id:")
-		fmt.Printf("   crm-admin contact create \"John Doe\" --company \"Acme Corp\"\n")
-		fmt.Printf("   crm-admin contact list\n")
-		fmt.Printf("   crm-admin note create \"Meeting\" \"Important discussion\" --contact-ids 1,2\n")
-		fmt.Printf("   crm-admin note list\n")
-		fmt.Println()
-		fmt.Printf("To switch users: crm-admin user select [other-user-id]\n")
-		fmt.Printf("To exit user mode: crm-admin user exit\n")
-
-		return nil
+		return newPrinter().Print(user, func() {
+			fmt.Printf("✅ Selected user: %s (%s)\n", user.Username, userID)
+			if local {
+				fmt.Printf("🎯 Context set for this directory - commands run here will now default to this user\n")
+			} else {
+				fmt.Printf("🎯 Context set - commands will now default to this user\n")
+			}
+			fmt.Println()
+			fmt.Println("You can now run commands without specifying --user-id:")
+			fmt.Printf("   crm-admin contact create \"John Doe\" --company \"Acme Corp\"\n")
+			fmt.Printf("   crm-admin contact list\n")
+			fmt.Printf("   crm-admin note create \"Meeting\" \"Important discussion\" --contact-ids 1,2\n")
+			fmt.Printf("   crm-admin note list\n")
+			fmt.Println()
+			fmt.Printf("To switch users: crm-admin user select [other-user]\n")
+			fmt.Printf("To exit user mode: crm-admin user exit\n")
+		})
 	},
 }
 
@@ -127,8 +172,10 @@ var userExitCmd = &cobra.Command{
 	Long:  `Exit the current user selection and return to global mode where user-id is required for operations.`,
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		printer := newPrinter()
+
 		if !context.HasUserContext() {
-			fmt.Println("No user is currently selected.")
+			fmt.Fprintln(printer.Messages(), "No user is currently selected.")
 			return nil
 		}
 
@@ -137,15 +184,31 @@ var userExitCmd = &cobra.Command{
 			return fmt.Errorf("failed to load user context: %w", err)
 		}
 
+		if userContext.FromProfile {
+			return fmt.Errorf("user %s is the default user of profile '%s' (remove default_user from the profile to exit)", userContext.UserID, config.ActiveProfileName())
+		}
+
 		err = context.ClearUserContext()
 		if err != nil {
 			return fmt.Errorf("failed to clear user context: %w", err)
 		}
 
-		fmt.Printf("✅ Exited user mode for: %s\n", userContext.Username)
-		fmt.Println("🌐 Back to global mode - you'll need to specify --user-id for operations")
+		user := models.User{
+			ID:       userContext.UserID,
+			Username: userContext.Username,
+		}
+
+		// Leaving a directory override falls back to the profile's selection, if any
+		remaining, _ := context.LoadUserContext()
 
-		return nil
+		return printer.Print(user, func() {
+			fmt.Printf("✅ Exited user mode for: %s\n", userContext.Username)
+			if remaining != nil {
+				fmt.Printf("🎯 Now using %s (%s)\n", remaining.Username, remaining.UserID)
+			} else {
+				fmt.Println("🌐 Back to global mode - you'll need to specify --user-id for operations")
+			}
+		})
 	},
 }
 
@@ -155,9 +218,11 @@ var userInfoCmd = &cobra.Command{
 	Long:  `Display information about the currently selected user and their data.`,
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		printer := newPrinter()
+
 		if !context.HasUserContext() {
-			fmt.Println("No user currently selected.")
-			fmt.Println("Use 'crm-admin user select [user-id]' to select a user.")
+			fmt.Fprintln(printer.Messages(), "No user currently selected.")
+			fmt.Fprintln(printer.Messages(), "Use 'crm-admin user select [user-id]' to select a user.")
 			return nil
 		}
 
@@ -166,13 +231,35 @@ var userInfoCmd = &cobra.Command{
 			return fmt.Errorf("failed to load user context: %w", err)
 		}
 
-		client := api.New()
+		client := newClient()
+
+		if printer.IsStructured() {
+			contacts, err := client.ListContacts(cmd.Context(), "")
+			if err != nil {
+				return fmt.Errorf("failed to list contacts: %w", err)
+			}
+
+			notes, err := client.ListNotesForUser(cmd.Context(), "")
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
+
+			info := userInfo{
+				User: models.User{
+					ID:       userContext.UserID,
+					Username: userContext.Username,
+				},
+				Contacts: contacts,
+				Notes:    notes,
+			}
+			return printer.Print(info, nil)
+		}
 
 		fmt.Printf("👤 Currently Selected User: %s (ID: %s)\n", userContext.Username, userContext.UserID)
 		fmt.Println("=" + fmt.Sprintf("%*s", 50, "="))
 
 		// Show contacts
-		contacts, err := client.ListContacts("")
+		contacts, err := client.ListContacts(cmd.Context(), "")
 		if err != nil {
 			fmt.Printf("❌ Failed to load contacts: %v\n", err)
 		} else {
@@ -191,7 +278,7 @@ var userInfoCmd = &cobra.Command{
 		}
 
 		// Show notes
-		notes, err := client.ListNotesForUser("")
+		notes, err := client.ListNotesForUser(cmd.Context(), "")
 		if err != nil {
 			fmt.Printf("❌ Failed to load notes: %v\n", err)
 		} else {
@@ -209,6 +296,198 @@ var userInfoCmd = &cobra.Command{
 	},
 }
 
+var userDeleteCmd = &cobra.Command{
+	Use:   "delete [user-id]",
+	Short: "Delete a user",
+	Long: `Delete a user together with all of their contacts and notes.
+
+A summary of the data that will be removed is shown first. Pass --yes to skip
+the confirmation prompt (required when not running in a terminal).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID := args[0]
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		client := newClient()
+
+		user, err := client.GetUser(cmd.Context(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		contacts, err := client.ListContacts(cmd.Context(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
+
+		notes, err := client.ListNotesForUser(cmd.Context(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to list notes: %w", err)
+		}
+
+		username := user.Username
+		if username == "" {
+			username = userID
+		}
+
+		printer := newPrinter()
+		messages := printer.Messages()
+
+		fmt.Fprintf(messages, "⚠️  Deleting user '%s' (%s) will also delete:\n", username, userID)
+		fmt.Fprintf(messages, "   %d contact(s)\n", len(contacts))
+		fmt.Fprintf(messages, "   %d note(s)\n", len(notes))
+		fmt.Fprintln(messages)
+
+		if !yes && !confirm("Are you sure you want to continue?") {
+			return fmt.Errorf("aborted (use --yes to delete without a prompt)")
+		}
+
+		err = client.DeleteUser(cmd.Context(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		// Drop the selections that pointed at the user we just deleted
+		contextCleared, err := context.ForgetUser(userID)
+		if err != nil {
+			return fmt.Errorf("failed to clear user context: %w", err)
+		}
+		if config.GetDefaultUser() == userID {
+			fmt.Fprintf(messages, "⚠️  The deleted user is still the default user of profile '%s'\n", config.ActiveProfileName())
+		}
+
+		result := userDeleteResult{
+			deleteResult: deleteResult{Kind: "user", ID: userID, Deleted: true},
+			Contacts:     len(contacts),
+			Notes:        len(notes),
+		}
+
+		return printer.Print(result, func() {
+			fmt.Printf("✅ User '%s' deleted successfully!\n", username)
+			if contextCleared {
+				if userContext, _ := context.LoadUserContext(); userContext != nil {
+					fmt.Printf("🌐 The deleted user was selected - %s is selected now\n", userContext.Username)
+				} else {
+					fmt.Println("🌐 The deleted user was selected - back to global mode")
+				}
+			}
+		})
+	},
+}
+
+var userRenameCmd = &cobra.Command{
+	Use:   "rename [user-id] [new-username]",
+	Short: "Rename a user",
+	Long:  `Change the username of an existing user.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+
+		user, err := client.RenameUser(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("failed to rename user: %w", err)
+		}
+
+		// Keep the selected user's display name in sync
+		userContext, _ := context.LoadUserContext()
+		if userContext != nil && userContext.UserID == user.ID && !userContext.FromProfile {
+			save := context.SaveUserContext
+			if userContext.Local {
+				save = context.SaveLocalUserContext
+			}
+			if err := save(user); err != nil {
+				return fmt.Errorf("failed to update user context: %w", err)
+			}
+		}
+
+		return newPrinter().Print(user, func() {
+			fmt.Printf("✅ User %s renamed to '%s'\n", user.ID, user.Username)
+		})
+	},
+}
+
+var userSetPasswordCmd = &cobra.Command{
+	Use:   "set-password [user-id]",
+	Short: "Reset a user's password",
+	Long: `Set a new password for a user.
+
+The password is never taken from the command line. It is read from a hidden
+prompt when running in a terminal, or from the first line of stdin otherwise:
+
+  echo "$NEW_PASSWORD" | crm-admin user set-password [user-id]`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword("New password: ")
+		if err != nil {
+			return err
+		}
+
+		client := newClient()
+
+		err = client.SetUserPassword(cmd.Context(), args[0], password)
+		if err != nil {
+			return fmt.Errorf("failed to set password: %w", err)
+		}
+
+		result := passwordResult{ID: args[0], PasswordUpdated: true}
+
+		return newPrinter().Print(result, func() {
+			fmt.Printf("✅ Password updated for user %s\n", args[0])
+		})
+	},
+}
+
+// resolveUser finds the user referred to by a full ID, a username or a unique ID prefix
+func resolveUser(ctx stdcontext.Context, client api.CRMService, ref string) (*models.User, error) {
+	// A full ID can be checked directly
+	user, err := client.GetUser(ctx, ref)
+	if err == nil && user.ID != "" {
+		if user.Username == "" {
+			user.Username = user.ID
+		}
+		return user, nil
+	}
+	// Only a reference that isn't an ID is worth searching for; other errors would
+	// fail the search as well
+	if err != nil && !api.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to look up user '%s': %w", ref, err)
+	}
+
+	users, err := client.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user '%s': %w", ref, err)
+	}
+
+	var matches []models.User
+	for _, user := range users {
+		if user.ID == ref || user.Username == ref {
+			matches = append(matches, user)
+		}
+	}
+
+	// Fall back to ID prefixes only when nothing matched exactly
+	if len(matches) == 0 {
+		for _, user := range users {
+			if strings.HasPrefix(user.ID, ref) {
+				matches = append(matches, user)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, notFoundError{fmt.Errorf("no user found matching '%s' (see 'crm-admin user list')", ref)}
+	case 1:
+		return &matches[0], nil
+	default:
+		var candidates strings.Builder
+		for _, user := range matches {
+			fmt.Fprintf(&candidates, "\n   %s  %s", user.ID, user.Username)
+		}
+		return nil, fmt.Errorf("'%s' matches %d users, please be more specific:%s", ref, len(matches), candidates.String())
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(userCmd)
 	userCmd.AddCommand(userCreateCmd)
@@ -216,4 +495,14 @@ func init() {
 	userCmd.AddCommand(userSelectCmd)
 	userCmd.AddCommand(userExitCmd)
 	userCmd.AddCommand(userInfoCmd)
+	userCmd.AddCommand(userDeleteCmd)
+	userCmd.AddCommand(userRenameCmd)
+	userCmd.AddCommand(userSetPasswordCmd)
+
+	// Flags for user select
+	userSelectCmd.Flags().Bool("local", false, "Only select the user for the current directory")
+	userSelectCmd.Flags().Bool("no-verify", false, "Don't look the user up on the backend (for offline use)")
+
+	// Flags for user delete
+	userDeleteCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
 }
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	stdcontext "context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/context"
+	"crm-admin/internal/fake"
+	"crm-admin/internal/models"
+)
+
+func TestUserCreateAndList(t *testing.T) {
+	setupFake(t)
+
+	var created models.User
+	runJSON(t, &created, "user", "create", "alice", "secret")
+	if created.Username != "alice" || created.ID == "" {
+		t.Fatalf("unexpected user: %+v", created)
+	}
+
+	_, err := run(t, "", "user", "create", "alice", "other")
+	if !api.IsConflict(err) {
+		t.Fatalf("creating a duplicate user: got %v, want a conflict", err)
+	}
+
+	var users []models.User
+	runJSON(t, &users, "user", "list")
+	if len(users) != 1 || users[0].ID != created.ID {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+}
+
+func TestUserSelect(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	service.CreateUser(ctx, "alan", "secret")
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantID  string
+		wantErr string
+	}{
+		{name: "full ID", ref: alice.ID, wantID: alice.ID},
+		{name: "username", ref: "alice", wantID: alice.ID},
+		{name: "ID prefix", ref: alice.ID[:8], wantID: alice.ID},
+		{name: "unknown", ref: "bob", wantErr: "no user found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, "", "user", "select", tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				if code := exitCode(err, false); code != exitNotFound {
+					t.Fatalf("exit code %d, want %d", code, exitNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			userContext, err := context.LoadUserContext()
+			if err != nil || userContext == nil {
+				t.Fatalf("no user context saved: %v", err)
+			}
+			if userContext.UserID != tt.wantID || userContext.Username != "alice" {
+				t.Fatalf("unexpected context: %+v", userContext)
+			}
+		})
+	}
+}
+
+// failingGetUser is a backend whose user lookups fail with err
+type failingGetUser struct {
+	*fake.Service
+	err error
+}
+
+func (f failingGetUser) GetUser(ctx stdcontext.Context, userID string) (*models.User, error) {
+	return nil, f.err
+}
+
+func TestResolveUserReturnsLookupErrors(t *testing.T) {
+	service := setupFake(t)
+	service.CreateUser(t.Context(), "alice", "secret")
+
+	// Only a 404 means the reference may be a username or prefix
+	for _, status := range []int{http.StatusUnauthorized, http.StatusInternalServerError} {
+		client := failingGetUser{Service: service, err: api.NewError(status, "")}
+		if _, err := resolveUser(t.Context(), client, "alice"); api.StatusCode(err) != status {
+			t.Fatalf("got %v, want the %d from the lookup", err, status)
+		}
+	}
+
+	client := failingGetUser{Service: service, err: api.NewError(http.StatusNotFound, "")}
+	if user, err := resolveUser(t.Context(), client, "alice"); err != nil || user.Username != "alice" {
+		t.Fatalf("got %+v, %v; want alice found by username", user, err)
+	}
+}
+
+func TestUserExit(t *testing.T) {
+	service := setupFake(t)
+	alice, _ := service.CreateUser(t.Context(), "alice", "secret")
+
+	mustRun(t, "user", "select", alice.ID)
+	mustRun(t, "user", "exit")
+
+	if context.HasUserContext() {
+		t.Fatal("user context still set after exit")
+	}
+}
+
+func TestUserInfo(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	jane, _ := service.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+	service.CreateNote(ctx, "Kickoff", "", []int{jane.ID}, alice.ID)
+
+	mustRun(t, "user", "select", "alice")
+
+	var info userInfo
+	runJSON(t, &info, "user", "info")
+	if info.User.ID != alice.ID || len(info.Contacts) != 1 || len(info.Notes) != 1 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
+
+func TestUserRenameAndSetPassword(t *testing.T) {
+	service := setupFake(t)
+	alice, _ := service.CreateUser(t.Context(), "alice", "secret")
+
+	var renamed models.User
+	runJSON(t, &renamed, "user", "rename", alice.ID, "alicia")
+	if renamed.Username != "alicia" {
+		t.Fatalf("unexpected user after rename: %+v", renamed)
+	}
+
+	if _, err := run(t, "new-secret\n", "user", "set-password", alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := service.Password(alice.ID); got != "new-secret" {
+		t.Fatalf("password = %q, want %q", got, "new-secret")
+	}
+}
+
+func TestUserDelete(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	service.CreateContact(ctx, "Jane", alice.ID, nil, nil, nil)
+
+	mustRun(t, "user", "select", alice.ID)
+	mustRun(t, "user", "delete", alice.ID, "--yes")
+
+	if _, err := service.GetUser(ctx, alice.ID); !api.IsNotFound(err) {
+		t.Fatalf("user still exists: %v", err)
+	}
+	if context.HasUserContext() {
+		t.Fatal("deleted user is still selected")
+	}
+
+	_, err := run(t, "", "user", "delete", alice.ID, "--yes")
+	if !api.IsNotFound(err) {
+		t.Fatalf("deleting a missing user: got %v, want not found", err)
+	}
+}
+
+func TestUserDeleteClearsOnlyItsSelection(t *testing.T) {
+	service := setupFake(t)
+	ctx := t.Context()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	bob, _ := service.CreateUser(ctx, "bob", "secret")
+
+	// Deleting a user that isn't selected keeps the selection
+	mustRun(t, "user", "select", alice.ID, "--local")
+	stdout := mustRun(t, "user", "delete", bob.ID, "--yes")
+	if strings.Contains(stdout, "was selected") {
+		t.Fatalf("reported a cleared selection:\n%s", stdout)
+	}
+	if userContext, _ := context.LoadUserContext(); userContext == nil || userContext.UserID != alice.ID {
+		t.Fatalf("selection changed: %+v", userContext)
+	}
+
+	// The profile's selection of the deleted user goes, the local override stays
+	carol, _ := service.CreateUser(ctx, "carol", "secret")
+	mustRun(t, "user", "select", alice.ID)
+	mustRun(t, "user", "select", carol.ID, "--local")
+
+	stdout = mustRun(t, "user", "delete", alice.ID, "--yes")
+	if !strings.Contains(stdout, "carol is selected now") {
+		t.Fatalf("got:\n%s", stdout)
+	}
+	if userContext, _ := context.LoadUserContext(); userContext == nil || userContext.UserID != carol.ID || !userContext.Local {
+		t.Fatalf("local selection lost: %+v", userContext)
+	}
+	os.Remove(".crm-context.json")
+	if userContext, _ := context.LoadUserContext(); userContext != nil {
+		t.Fatalf("deleted user still selected in the profile: %+v", userContext)
+	}
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"crm-admin/internal/fsutil"
+)
+
+// redactedHeaders are replaced with redactedValue before an interaction is written.
+// Password, token and secret fields of JSON bodies are masked like in traces.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+const redactedValue = "REDACTED"
+
+// errNoInteraction is returned in replay mode when no recorded interaction matches a
+// request. It is never retried.
+var errNoInteraction = errors.New("no recorded interaction matches the request")
+
+// interaction is one request/response pair stored in a cassette file
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// recorder sends requests to next and writes every request/response pair to a
+// numbered cassette file in dir, for example 0003-get-api-user.json
+type recorder struct {
+	next Doer
+	dir  string
+
+	mu   sync.Mutex
+	seq  int
+	scan bool
+}
+
+func newRecorder(next Doer, dir string) *recorder {
+	return &recorder{next: next, dir: dir}
+}
+
+func (r *recorder) Do(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			URL:    req.URL.Redacted(),
+			Header: redactHeader(req.Header),
+			Body:   string(maskJSON(reqBody)),
+		},
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     redactHeader(resp.Header),
+			Body:       string(maskJSON(respBody)),
+		},
+	}
+	if err := r.write(recorded); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *recorder) write(recorded interaction) error {
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal interaction: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Continue the numbering of earlier runs recording into the same directory
+	if !r.scan {
+		files, err := cassetteFiles(r.dir)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if len(files) > 0 {
+			r.seq = cassetteIndex(files[len(files)-1])
+		}
+		r.scan = true
+	}
+
+	r.seq++
+	name := fmt.Sprintf("%04d-%s.json", r.seq, slug(recorded.Request.Method+" "+requestPath(recorded.Request.URL)))
+	if err := fsutil.WriteFileAtomic(filepath.Join(r.dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// replayer answers requests from the cassette files in dir without using the network.
+// A request matches an interaction with the same method, path, query and body, where
+// the body is compared after masking as it was recorded; each interaction is used
+// once, in the order it was recorded.
+type replayer struct {
+	dir string
+
+	once         sync.Once
+	loadErr      error
+	mu           sync.Mutex
+	interactions []interaction
+	used         []bool
+}
+
+func newReplayer(dir string) *replayer {
+	return &replayer{dir: dir}
+}
+
+func (r *replayer) Do(req *http.Request) (*http.Response, error) {
+	r.once.Do(r.load)
+	if r.loadErr != nil {
+		return nil, r.loadErr
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	masked := string(maskJSON(body))
+	path := req.URL.RequestURI()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, recorded := range r.interactions {
+		if r.used[i] || recorded.Request.Method != req.Method || requestPath(recorded.Request.URL) != path ||
+			(recorded.Request.Body != masked && recorded.Request.Body != string(body)) {
+			continue
+		}
+		r.used[i] = true
+
+		resp := &http.Response{
+			StatusCode:    recorded.Response.StatusCode,
+			Status:        recorded.Response.Status,
+			Header:        recorded.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(recorded.Response.Body)),
+			ContentLength: int64(len(recorded.Response.Body)),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s (cassettes in %s)", errNoInteraction, req.Method, path, r.dir)
+}
+
+func (r *replayer) load() {
+	files, err := cassetteFiles(r.dir)
+	if err != nil {
+		r.loadErr = fmt.Errorf("failed to read cassettes: %w", err)
+		return
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(r.dir, file))
+		if err != nil {
+			r.loadErr = fmt.Errorf("failed to read cassette: %w", err)
+			return
+		}
+		var recorded interaction
+		if err := json.Unmarshal(data, &recorded); err != nil {
+			r.loadErr = fmt.Errorf("failed to parse cassette %s: %w", file, err)
+			return
+		}
+		r.interactions = append(r.interactions, recorded)
+	}
+	r.used = make([]bool, len(r.interactions))
+}
+
+// cassetteFiles returns the cassette file names in dir in recording order
+func cassetteFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && cassetteIndex(entry.Name()) > 0 {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return cassetteIndex(files[i]) < cassetteIndex(files[j]) })
+	return files, nil
+}
+
+// cassetteIndex returns the sequence number a cassette file name starts with, or 0
+func cassetteIndex(name string) int {
+	digits, _, _ := strings.Cut(name, "-")
+	index, _ := strconv.Atoi(strings.TrimSuffix(digits, ".json"))
+	return index
+}
+
+// readRequestBody returns the request body and replaces it so it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, redactedValue)
+		}
+	}
+	return redacted
+}
+
+// requestPath returns the path and query of a recorded URL, so cassettes replay
+// against any host
+func requestPath(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.RequestURI()
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns "GET /api/users/42/contacts" into "get-api-users-42-contacts"
+func slug(text string) string {
+	text = strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if len(text) > 60 {
+		text = strings.TrimRight(text[:60], "-")
+	}
+	return text
+}
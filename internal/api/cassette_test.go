@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CRM_ADMIN_API_KEY", "secret-token")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user":
+			w.Write([]byte(`[{"id":"u1","username":"alice"}]`))
+		case "/api/user/u2":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"user u2 not found"}`))
+		}
+	}))
+
+	recording := &Client{transport: newRecorder(http.DefaultClient, dir), baseURL: server.URL}
+	if _, err := recording.ListUsers(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recording.GetUser(t.Context(), "u2"); !IsNotFound(err) {
+		t.Fatalf("recording: got %v, want not found", err)
+	}
+	server.Close()
+
+	files, err := cassetteFiles(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("cassette files = %v, %v", files, err)
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(filepath.Join(dir, file))
+		if strings.Contains(string(data), "secret-token") {
+			t.Fatalf("%s contains the token:\n%s", file, data)
+		}
+	}
+
+	// Replay works without the server, against any host
+	replaying := &Client{transport: newReplayer(dir), baseURL: "http://offline.invalid", retry: retryPolicy{maxRetries: 3}}
+
+	users, err := replaying.ListUsers(t.Context())
+	if err != nil || len(users) != 1 || users[0].Username != "alice" {
+		t.Fatalf("replayed users = %+v, %v", users, err)
+	}
+	if _, err := replaying.GetUser(t.Context(), "u2"); !IsNotFound(err) {
+		t.Fatalf("replaying: got %v, want not found", err)
+	}
+
+	// Every interaction is used once
+	if _, err := replaying.ListUsers(t.Context()); !errors.Is(err, errNoInteraction) {
+		t.Fatalf("second replay: got %v, want errNoInteraction", err)
+	}
+}
+
+func TestRecordMasksSecrets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CRM_ADMIN_API_KEY", "secret-token")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("id", "u1")
+		w.Header().Set("Set-Cookie", "session=cookie-secret; Path=/")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"u1","username":"alice","refreshToken":"issued-token"}`))
+	}))
+
+	recording := &Client{transport: newRecorder(http.DefaultClient, dir), baseURL: server.URL}
+	if _, err := recording.CreateUser(t.Context(), "alice", "hunter2-password"); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	files, err := cassetteFiles(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("cassette files = %v, %v", files, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, files[0]))
+	for _, secret := range []string{"hunter2-password", "issued-token", "secret-token", "cookie-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "alice") {
+		t.Fatalf("cassette lost the username:\n%s", data)
+	}
+
+	// The masked request body still matches when replayed
+	replaying := &Client{transport: newReplayer(dir), baseURL: "http://offline.invalid"}
+	user, err := replaying.CreateUser(t.Context(), "alice", "hunter2-password")
+	if err != nil || user.ID != "u1" {
+		t.Fatalf("replayed user = %+v, %v", user, err)
+	}
+}
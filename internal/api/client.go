@@ -2,26 +2,26 @@ package api
 
 import (
 	"bytes"
+	stdcontext "context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"net/url"
 
 	"crm-admin/internal/config"
 	"crm-admin/internal/context"
 	"crm-admin/internal/models"
 )
 
-const (
-	RequestTimeout = 30 * time.Second
-)
-
 type Client struct {
-	httpClient    *http.Client
-	baseURL       string
-	contextualURL string
-	userContext   *context.UserContext
+	transport       Doer // the HTTP client, or a cassette recorder or replayer
+	baseURL         string
+	contextualURL   string
+	userContext     *context.UserContext
+	retry           retryPolicy
+	idempotencyKeys bool
+	middleware      []Middleware
 }
 
 // New creates a new API client
@@ -29,29 +29,42 @@ func New() *Client {
 	baseURL := config.GetBaseURL()
 	contextualURL, userContext := context.GetContextualBaseURL(baseURL)
 
+	var transport Doer = &http.Client{
+		Timeout: config.GetTimeout(),
+	}
+	if dir := config.GetReplayDir(); dir != "" {
+		transport = newReplayer(dir)
+	} else if dir := config.GetRecordDir(); dir != "" {
+		transport = newRecorder(transport, dir)
+	}
+
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: RequestTimeout,
-		},
+		transport:     transport,
 		baseURL:       baseURL,
 		contextualURL: contextualURL,
 		userContext:   userContext,
+		retry: retryPolicy{
+			maxRetries: config.GetRetries(),
+			deadline:   config.GetDeadline(),
+			baseDelay:  retryBaseDelay,
+			maxDelay:   retryMaxDelay,
+		},
+		idempotencyKeys: config.UseIdempotencyKeys(),
 	}
 }
 
 // User operations - use correct existing endpoints
-func (c *Client) CreateUser(username, password string) (*models.User, error) {
+func (c *Client) CreateUser(ctx stdcontext.Context, username, password string) (*models.User, error) {
 	userReq := models.UserRequest{
 		Username: username,
 		Password: password,
 	}
 
 	// Using the actual endpoint from your backend
-	resp, err := c.postWithAuthRaw("/api/user", userReq)
+	resp, err := c.roundTrip(ctx, http.MethodPost, "/api/user", userReq, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Your backend returns the ID in the header, not in the response body
 	userID := resp.Header.Get("id")
@@ -65,26 +78,83 @@ func (c *Client) CreateUser(username, password string) (*models.User, error) {
 	}, nil
 }
 
-func (c *Client) ListUsers() ([]models.User, error) {
+func (c *Client) ListUsers(ctx stdcontext.Context) ([]models.User, error) {
 	var users []models.User
-	err := c.getWithAuth("/api/user", &users)
+	err := c.do(ctx, http.MethodGet, "/api/user", nil, &users)
 	return users, err
 }
 
-func (c *Client) GetUser(userID string) (*models.User, error) {
+func (c *Client) GetUser(ctx stdcontext.Context, userID string) (*models.User, error) {
+	// The ID may be something a user typed, such as a username given to 'user select'
 	var user models.User
-	url := fmt.Sprintf("/api/user/%s", userID)
-	err := c.getWithAuth(url, &user)
+	err := c.do(ctx, http.MethodGet, "/api/user/"+url.PathEscape(userID), nil, &user)
 	return &user, err
 }
 
+func (c *Client) RenameUser(ctx stdcontext.Context, userID, username string) (*models.User, error) {
+	userReq := models.UserUpdateRequest{
+		Username: username,
+	}
+
+	var user models.User
+	url := fmt.Sprintf("/api/user/%s", userID)
+	err := c.do(ctx, http.MethodPut, url, userReq, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in anything the backend leaves out of the response
+	if user.ID == "" {
+		user.ID = userID
+	}
+	if user.Username == "" {
+		user.Username = username
+	}
+	return &user, nil
+}
+
+func (c *Client) SetUserPassword(ctx stdcontext.Context, userID, password string) error {
+	userReq := models.UserUpdateRequest{
+		Password: password,
+	}
+
+	url := fmt.Sprintf("/api/user/%s/password", userID)
+	return c.do(ctx, http.MethodPut, url, userReq, nil)
+}
+
+func (c *Client) DeleteUser(ctx stdcontext.Context, userID string) error {
+	url := fmt.Sprintf("/api/user/%s", userID)
+	return c.do(ctx, http.MethodDelete, url, nil, nil)
+}
+
+// Login exchanges a username and password for a token at endpoint. The request is
+// sent without the admin token.
+func (c *Client) Login(ctx stdcontext.Context, endpoint, username, password string) (*models.TokenResponse, error) {
+	loginReq := models.LoginRequest{
+		Username: username,
+		Password: password,
+	}
+
+	var token models.TokenResponse
+	if err := c.do(withoutAuth(ctx), http.MethodPost, endpoint, loginReq, &token); err != nil {
+		return nil, err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return nil, fmt.Errorf("no token in the login response")
+	}
+	return &token, nil
+}
+
 // GetBaseURL returns the base URL for display purposes
 func (c *Client) GetBaseURL() string {
 	return c.baseURL
 }
 
 // Contact operations - use correct existing endpoints
-func (c *Client) CreateContact(name, userID string, company, phoneNumber, contactEmail *string) (*models.Contact, error) {
+func (c *Client) CreateContact(ctx stdcontext.Context, name, userID string, company, phoneNumber, contactEmail *string) (*models.Contact, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -105,16 +175,16 @@ func (c *Client) CreateContact(name, userID string, company, phoneNumber, contac
 
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
-		err := c.postWithAuth("/contacts", contactReq, &contact)
+		err := c.do(ctx, http.MethodPost, "/contacts", contactReq, &contact)
 		return &contact, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts", targetUserID)
-		err := c.postWithAuth(url, contactReq, &contact)
+		err := c.do(ctx, http.MethodPost, url, contactReq, &contact)
 		return &contact, err
 	}
 }
 
-func (c *Client) ListContacts(userID string) ([]models.Contact, error) {
+func (c *Client) ListContacts(ctx stdcontext.Context, userID string) ([]models.Contact, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -128,24 +198,92 @@ func (c *Client) ListContacts(userID string) ([]models.Contact, error) {
 
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
-		err := c.getWithAuth("/contacts", &contacts)
+		err := c.do(ctx, http.MethodGet, "/contacts", nil, &contacts)
 		return contacts, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts", targetUserID)
-		err := c.getWithAuth(url, &contacts)
+		err := c.do(ctx, http.MethodGet, url, nil, &contacts)
 		return contacts, err
 	}
 }
 
-func (c *Client) GetContact(userID string, contactID int) (*models.Contact, error) {
+func (c *Client) GetContact(ctx stdcontext.Context, userID string, contactID int) (*models.Contact, error) {
+	// Use provided userID or fall back to context
+	targetUserID := userID
+	if targetUserID == "" && c.userContext != nil {
+		targetUserID = c.userContext.UserID
+	}
+	if targetUserID == "" {
+		return nil, fmt.Errorf("user ID is required (use --user-id flag or select a user first)")
+	}
+
 	var contact models.Contact
-	url := fmt.Sprintf("/api/users/%s/contacts/%d", userID, contactID)
-	err := c.getWithAuth(url, &contact)
-	return &contact, err
+
+	// Use contextual URL if we have context and no explicit userID was provided
+	if userID == "" && c.userContext != nil {
+		url := fmt.Sprintf("/contacts/%d", contactID)
+		err := c.do(ctx, http.MethodGet, url, nil, &contact)
+		return &contact, err
+	} else {
+		url := fmt.Sprintf("/api/users/%s/contacts/%d", targetUserID, contactID)
+		err := c.do(ctx, http.MethodGet, url, nil, &contact)
+		return &contact, err
+	}
+}
+
+func (c *Client) UpdateContact(ctx stdcontext.Context, userID string, contactID int, name string, company, phoneNumber, contactEmail *string) (*models.Contact, error) {
+	// Use provided userID or fall back to context
+	targetUserID := userID
+	if targetUserID == "" && c.userContext != nil {
+		targetUserID = c.userContext.UserID
+	}
+	if targetUserID == "" {
+		return nil, fmt.Errorf("user ID is required (use --user-id flag or select a user first)")
+	}
+
+	contactReq := models.ContactRequest{
+		Name:         name,
+		Company:      company,
+		PhoneNumber:  phoneNumber,
+		ContactEmail: contactEmail,
+	}
+
+	var contact models.Contact
+
+	// Use contextual URL if we have context and no explicit userID was provided
+	if userID == "" && c.userContext != nil {
+		url := fmt.Sprintf("/contacts/%d", contactID)
+		err := c.do(ctx, http.MethodPut, url, contactReq, &contact)
+		return &contact, err
+	} else {
+		url := fmt.Sprintf("/api/users/%s/contacts/%d", targetUserID, contactID)
+		err := c.do(ctx, http.MethodPut, url, contactReq, &contact)
+		return &contact, err
+	}
+}
+
+func (c *Client) DeleteContact(ctx stdcontext.Context, userID string, contactID int) error {
+	// Use provided userID or fall back to context
+	targetUserID := userID
+	if targetUserID == "" && c.userContext != nil {
+		targetUserID = c.userContext.UserID
+	}
+	if targetUserID == "" {
+		return fmt.Errorf("user ID is required (use --user-id flag or select a user first)")
+	}
+
+	// Use contextual URL if we have context and no explicit userID was provided
+	if userID == "" && c.userContext != nil {
+		url := fmt.Sprintf("/contacts/%d", contactID)
+		return c.do(ctx, http.MethodDelete, url, nil, nil)
+	} else {
+		url := fmt.Sprintf("/api/users/%s/contacts/%d", targetUserID, contactID)
+		return c.do(ctx, http.MethodDelete, url, nil, nil)
+	}
 }
 
 // Note operations - use correct existing endpoints
-func (c *Client) CreateNote(title, description string, contactIDs []int, userID string) (*models.Note, error) {
+func (c *Client) CreateNote(ctx stdcontext.Context, title, description string, contactIDs []int, userID string) (*models.Note, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -165,16 +303,16 @@ func (c *Client) CreateNote(title, description string, contactIDs []int, userID
 
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
-		err := c.postWithAuth("/contacts/notes", noteReq, &note)
+		err := c.do(ctx, http.MethodPost, "/contacts/notes", noteReq, &note)
 		return &note, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts/notes", targetUserID)
-		err := c.postWithAuth(url, noteReq, &note)
+		err := c.do(ctx, http.MethodPost, url, noteReq, &note)
 		return &note, err
 	}
 }
 
-func (c *Client) ListNotesForUser(userID string) ([]models.Note, error) {
+func (c *Client) ListNotesForUser(ctx stdcontext.Context, userID string) ([]models.Note, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -188,16 +326,16 @@ func (c *Client) ListNotesForUser(userID string) ([]models.Note, error) {
 
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
-		err := c.getWithAuth("/contacts/notes", &notes)
+		err := c.do(ctx, http.MethodGet, "/contacts/notes", nil, &notes)
 		return notes, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts/notes", targetUserID)
-		err := c.getWithAuth(url, &notes)
+		err := c.do(ctx, http.MethodGet, url, nil, &notes)
 		return notes, err
 	}
 }
 
-func (c *Client) ListNotesForContact(userID string, contactID int) ([]models.Note, error) {
+func (c *Client) ListNotesForContact(ctx stdcontext.Context, userID string, contactID int) ([]models.Note, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -212,16 +350,16 @@ func (c *Client) ListNotesForContact(userID string, contactID int) ([]models.Not
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
 		url := fmt.Sprintf("/contacts/%d/notes", contactID)
-		err := c.getWithAuth(url, &notes)
+		err := c.do(ctx, http.MethodGet, url, nil, &notes)
 		return notes, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts/%d/notes", targetUserID, contactID)
-		err := c.getWithAuth(url, &notes)
+		err := c.do(ctx, http.MethodGet, url, nil, &notes)
 		return notes, err
 	}
 }
 
-func (c *Client) GetNote(userID string, noteID int) (*models.Note, error) {
+func (c *Client) GetNote(ctx stdcontext.Context, userID string, noteID int) (*models.Note, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -236,16 +374,16 @@ func (c *Client) GetNote(userID string, noteID int) (*models.Note, error) {
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
 		url := fmt.Sprintf("/contacts/notes/%d", noteID)
-		err := c.getWithAuth(url, &note)
+		err := c.do(ctx, http.MethodGet, url, nil, &note)
 		return &note, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts/notes/%d", targetUserID, noteID)
-		err := c.getWithAuth(url, &note)
+		err := c.do(ctx, http.MethodGet, url, nil, &note)
 		return &note, err
 	}
 }
 
-func (c *Client) UpdateNote(userID string, noteID int, title, description string, contactIDs []int) (*models.Note, error) {
+func (c *Client) UpdateNote(ctx stdcontext.Context, userID string, noteID int, title, description string, contactIDs []int) (*models.Note, error) {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -266,16 +404,16 @@ func (c *Client) UpdateNote(userID string, noteID int, title, description string
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
 		url := fmt.Sprintf("/contacts/notes/%d", noteID)
-		err := c.putWithAuth(url, noteReq, &note)
+		err := c.do(ctx, http.MethodPut, url, noteReq, &note)
 		return &note, err
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts/notes/%d", targetUserID, noteID)
-		err := c.putWithAuth(url, noteReq, &note)
+		err := c.do(ctx, http.MethodPut, url, noteReq, &note)
 		return &note, err
 	}
 }
 
-func (c *Client) DeleteNote(userID string, noteID int) error {
+func (c *Client) DeleteNote(ctx stdcontext.Context, userID string, noteID int) error {
 	// Use provided userID or fall back to context
 	targetUserID := userID
 	if targetUserID == "" && c.userContext != nil {
@@ -288,171 +426,96 @@ func (c *Client) DeleteNote(userID string, noteID int) error {
 	// Use contextual URL if we have context and no explicit userID was provided
 	if userID == "" && c.userContext != nil {
 		url := fmt.Sprintf("/contacts/notes/%d", noteID)
-		return c.deleteWithAuth(url)
+		return c.do(ctx, http.MethodDelete, url, nil, nil)
 	} else {
 		url := fmt.Sprintf("/api/users/%s/contacts/notes/%d", targetUserID, noteID)
-		return c.deleteWithAuth(url)
+		return c.do(ctx, http.MethodDelete, url, nil, nil)
 	}
 }
 
-// Helper methods with admin JWT authentication
-func (c *Client) postWithAuth(endpoint string, data interface{}, result interface{}) error {
-	resp, err := c.postWithAuthRaw(endpoint, data)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// Use adds middleware to the request pipeline. Middleware runs for every attempt,
+// after authentication and retry handling, in the order it was added.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+// do sends a request through the pipeline and decodes a JSON response into out.
+// out may be nil when the response body isn't needed.
+func (c *Client) do(ctx stdcontext.Context, method, endpoint string, body, out interface{}) error {
+	_, err := c.roundTrip(ctx, method, endpoint, body, out)
+	return err
+}
+
+// roundTrip is do for callers that also need the response headers. The returned
+// response's body has already been consumed and closed.
+func (c *Client) roundTrip(ctx stdcontext.Context, method, endpoint string, body, out interface{}) (*http.Response, error) {
+	var payload io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal data: %w", err)
 		}
+		payload = bytes.NewReader(jsonData)
 	}
 
-	return nil
-}
-
-func (c *Client) putWithAuth(endpoint string, data interface{}, result interface{}) error {
-	jsonData, err := json.Marshal(data)
+	req, err := http.NewRequestWithContext(ctx, method, c.resolveURL(endpoint), payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Choose URL based on context
-	var fullURL string
-	if c.userContext != nil && !isAbsoluteEndpoint(endpoint) {
-		fullURL = c.contextualURL + endpoint
-	} else {
-		fullURL = c.baseURL + endpoint
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	req, err := http.NewRequest("PUT", fullURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	// An idempotency key lets the backend deduplicate retried POSTs
+	if method == http.MethodPost && c.idempotencyKeys {
+		req.Header.Set(idempotencyKeyHeader, newIdempotencyKey())
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+config.GetAdminToken())
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.pipeline().Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (%s): %s", resp.Status, string(body))
+	if !isSuccess(resp) {
+		return nil, newError(resp)
 	}
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
 
-	return nil
+	return resp, nil
 }
 
-func (c *Client) deleteWithAuth(endpoint string) error {
-	// Choose URL based on context
-	var fullURL string
-	if c.userContext != nil && !isAbsoluteEndpoint(endpoint) {
-		fullURL = c.contextualURL + endpoint
-	} else {
-		fullURL = c.baseURL + endpoint
-	}
-
-	req, err := http.NewRequest("DELETE", fullURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// pipeline assembles the middleware chain: authentication, then retries, then any
+// middleware added with Use, and finally the transport
+func (c *Client) pipeline() Doer {
+	doer := c.transport
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		doer = c.middleware[i](doer)
 	}
-
-	req.Header.Set("Authorization", "Bearer "+config.GetAdminToken())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (%s): %s", resp.Status, string(body))
-	}
-
-	return nil
+	doer = retryMiddleware(c.retry)(doer)
+	doer = authMiddleware(c.baseURL)(doer)
+	return doer
 }
 
-func (c *Client) postWithAuthRaw(endpoint string, data interface{}) (*http.Response, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
-
-	// Choose URL based on context
-	var fullURL string
+// resolveURL chooses between the contextual and the base URL for an endpoint
+func (c *Client) resolveURL(endpoint string) string {
 	if c.userContext != nil && !isAbsoluteEndpoint(endpoint) {
-		fullURL = c.contextualURL + endpoint
-	} else {
-		fullURL = c.baseURL + endpoint
+		return c.contextualURL + endpoint
 	}
-
-	req, err := http.NewRequest("POST", fullURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+config.GetAdminToken())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		return nil, fmt.Errorf("API error (%s): %s", resp.Status, string(body))
-	}
-
-	return resp, nil
+	return c.baseURL + endpoint
 }
 
-func (c *Client) getWithAuth(endpoint string, result interface{}) error {
-	// Choose URL based on context
-	var fullURL string
-	if c.userContext != nil && !isAbsoluteEndpoint(endpoint) {
-		fullURL = c.contextualURL + endpoint
-	} else {
-		fullURL = c.baseURL + endpoint
-	}
-
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+config.GetAdminToken())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Accept both 200 OK and 302 Found (temporary fix for backend issue)
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusFound {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (%s): %s", resp.Status, string(body))
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return nil
+// isSuccess accepts every 2xx status. Redirects the HTTP client didn't follow are
+// errors, as they mean the request went to the wrong place.
+func isSuccess(resp *http.Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
 }
 
 // isAbsoluteEndpoint checks if the endpoint starts with /api (absolute path)
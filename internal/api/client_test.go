@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoStatusCodes(t *testing.T) {
+	t.Setenv("CRM_ADMIN_API_KEY", "secret-token")
+
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    bool
+		wantStatus int
+		wantName   string
+	}{
+		{name: "200 decodes the body", status: http.StatusOK, body: `{"id":1,"name":"Jane"}`, wantName: "Jane"},
+		{name: "201 decodes the body", status: http.StatusCreated, body: `{"id":2,"name":"John"}`, wantName: "John"},
+		{name: "204 without body", status: http.StatusNoContent},
+		{name: "200 with empty body", status: http.StatusOK},
+		{name: "302 without Location", status: http.StatusFound, wantErr: true, wantStatus: http.StatusFound},
+		{name: "300", status: http.StatusMultipleChoices, wantErr: true, wantStatus: http.StatusMultipleChoices},
+		{name: "304", status: http.StatusNotModified, wantErr: true, wantStatus: http.StatusNotModified},
+		{name: "404", status: http.StatusNotFound, body: `{"message":"contact not found"}`, wantErr: true, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := &Client{transport: http.DefaultClient, baseURL: server.URL}
+			var out struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			}
+			err := client.do(t.Context(), http.MethodGet, "/api/contacts/1", nil, &out)
+
+			if tt.wantErr {
+				if StatusCode(err) != tt.wantStatus {
+					t.Fatalf("got error %v, want an API error with status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Name != tt.wantName {
+				t.Fatalf("decoded %+v, want name %q", out, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestGetUserEscapesID(t *testing.T) {
+	t.Setenv("CRM_ADMIN_API_KEY", "secret-token")
+
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		w.Write([]byte(`{"id":"u1","username":"alice"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{transport: http.DefaultClient, baseURL: server.URL}
+	if _, err := client.GetUser(t.Context(), "al/ice?x=1"); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/api/user/al%2Fice%3Fx=1" {
+		t.Fatalf("requested %s", path)
+	}
+}
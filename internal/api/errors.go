@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBody limits how much of an error response is read into memory
+const maxErrorBody = 64 * 1024
+
+// Error is returned when the backend answers with an unexpected status code
+type Error struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+
+	// Message is the backend's error message: the "message" or "error" field of a JSON
+	// body, or the raw body text otherwise
+	Message string
+
+	// Body is the raw response body
+	Body []byte
+
+	// RetryAfter is how long the backend asked to wait before trying again, from the
+	// Retry-After header; 0 when it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	text := fmt.Sprintf("API error (%s)", e.Status)
+	if e.Method != "" {
+		text += fmt.Sprintf(" for %s %s", e.Method, e.URL)
+	}
+	if e.Message != "" {
+		text += ": " + e.Message
+	}
+	if e.RetryAfter > 0 {
+		text += fmt.Sprintf(" (retry after %s)", e.RetryAfter.Round(time.Second))
+	}
+	return text
+}
+
+// errorBody covers the error formats the backend and its proxies return
+type errorBody struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+	Detail  string `json:"detail"`
+}
+
+// NewError creates an *Error for a status code without an HTTP exchange,
+// for example from a fake backend
+func NewError(statusCode int, message string) *Error {
+	return &Error{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Message:    message,
+	}
+}
+
+// newError builds an *Error from a failed response and consumes its body
+func newError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		apiErr.RetryAfter = wait
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.URL = resp.Request.URL.Redacted()
+	}
+
+	var parsed errorBody
+	if json.Unmarshal(body, &parsed) == nil {
+		switch {
+		case parsed.Message != "":
+			apiErr.Message = parsed.Message
+		case parsed.Detail != "":
+			apiErr.Message = parsed.Detail
+		case parsed.Error != "":
+			apiErr.Message = parsed.Error
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+// StatusCode returns the HTTP status of an API error anywhere in err's chain, or 0
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 from the backend
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether the backend rejected the credentials (401 or 403)
+func IsUnauthorized(err error) bool {
+	code := StatusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// IsConflict reports whether err is a 409 from the backend
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsServerError reports whether err is a 5xx from the backend
+func IsServerError(err error) bool {
+	return StatusCode(err) >= 500
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		header      http.Header
+		wantMessage string
+		wantError   string
+		check       func(error) bool
+	}{
+		{name: "message field", status: 404, body: `{"message":"user 42 not found"}`, wantMessage: "user 42 not found", check: IsNotFound},
+		{name: "detail field", status: 409, body: `{"detail":"username taken","error":"Conflict"}`, wantMessage: "username taken", check: IsConflict},
+		{name: "error field", status: 401, body: `{"error":"invalid token"}`, wantMessage: "invalid token", check: IsUnauthorized},
+		{name: "forbidden", status: 403, body: `{}`, wantMessage: "{}", check: IsUnauthorized},
+		{name: "plain text", status: 502, body: " Bad Gateway \n", wantMessage: "Bad Gateway", check: IsServerError},
+		{name: "empty body", status: 500, body: "", wantMessage: "", check: IsServerError},
+		{name: "client error", status: 400, body: `{"message":"name is required"}`, wantMessage: "name is required",
+			check: func(err error) bool { return !IsServerError(err) && !IsNotFound(err) && StatusCode(err) == 400 }},
+		{name: "retry after", status: 429, body: `{"message":"slow down"}`, header: http.Header{"Retry-After": {"86400"}}, wantMessage: "slow down",
+			wantError: "API error (429 Too Many Requests) for GET http://backend.invalid/api/user/42: slow down (retry after 24h0m0s)"},
+		{name: "error text", status: 404, body: `{"message":"gone"}`, wantMessage: "gone", wantError: "API error (404 Not Found) for GET http://backend.invalid/api/user/42: gone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://backend.invalid/api/user/42", nil)
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Status:     fmt.Sprintf("%d %s", tt.status, http.StatusText(tt.status)),
+				Header:     tt.header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+				Request:    req,
+			}
+
+			apiErr := newError(resp)
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage || string(apiErr.Body) != tt.body {
+				t.Fatalf("got %+v, want status %d and message %q", apiErr, tt.status, tt.wantMessage)
+			}
+			if tt.wantError != "" && apiErr.Error() != tt.wantError {
+				t.Fatalf("Error() = %q, want %q", apiErr.Error(), tt.wantError)
+			}
+
+			// The helpers see through wrapping
+			wrapped := fmt.Errorf("failed to get user: %w", apiErr)
+			if tt.check != nil && !tt.check(wrapped) {
+				t.Fatalf("check failed for %v", wrapped)
+			}
+		})
+	}
+}
+
+func TestNewErrorLimitsBody(t *testing.T) {
+	resp := &http.Response{StatusCode: 500, Status: "500 Internal Server Error", Body: io.NopCloser(strings.NewReader(strings.Repeat("x", 2*maxErrorBody)))}
+	if apiErr := newError(resp); len(apiErr.Body) != maxErrorBody {
+		t.Fatalf("read %d bytes of the body, want %d", len(apiErr.Body), maxErrorBody)
+	}
+}
+
+func TestStatusCodeWithoutAPIError(t *testing.T) {
+	err := errors.New("connection refused")
+	if StatusCode(err) != 0 || IsNotFound(err) || IsUnauthorized(err) || IsConflict(err) || IsServerError(err) {
+		t.Fatalf("%v is not an API error", err)
+	}
+	if StatusCode(nil) != 0 {
+		t.Fatal("nil has no status code")
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"crm-admin/internal/config"
+	"crm-admin/internal/credentials"
+)
+
+// Doer sends a single HTTP request. *http.Client satisfies it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to the Doer interface
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer to observe or change requests and responses,
+// for example for logging, tracing or recording
+type Middleware func(next Doer) Doer
+
+// authMiddleware adds the admin bearer token to every request. Without a token the
+// request is not sent at all, since the backend would only answer with a 401; replayed
+// requests and requests made with withoutAuth are the exception. Requests made with
+// WithToken carry that token instead.
+//
+// When a token from 'auth login' is rejected with a 401 and a refresh endpoint is
+// configured, the token is refreshed and the request is sent once more.
+func authMiddleware(baseURL string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if skipAuth, _ := req.Context().Value(withoutAuthKey{}).(bool); skipAuth {
+				return next.Do(req)
+			}
+			if token, ok := req.Context().Value(withTokenKey{}).(string); ok {
+				req.Header.Set("Authorization", "Bearer "+token)
+				return next.Do(req)
+			}
+
+			token, source, err := credentials.Token()
+			if err != nil {
+				return nil, err
+			}
+			if token == "" {
+				if config.GetReplayDir() != "" {
+					return next.Do(req)
+				}
+				return nil, fmt.Errorf("%w for profile '%s' (run 'crm-admin auth login' or set CRM_ADMIN_API_KEY)", credentials.ErrNoCredential, config.ActiveProfileName())
+			}
+
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := next.Do(req)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(source, "login:") || config.GetRefreshEndpoint() == "" {
+				return resp, err
+			}
+
+			refreshed, refreshErr := refreshToken(req.Context(), next, baseURL, token)
+			if refreshErr != nil {
+				// Report the original 401; the refresh failure is secondary
+				return resp, nil
+			}
+			if err := rewindBody(req); err != nil {
+				return resp, nil
+			}
+
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			req.Header.Set("Authorization", "Bearer "+refreshed)
+			return next.Do(req)
+		})
+	}
+}
+
+type withoutAuthKey struct{}
+
+// withoutAuth marks requests made with ctx as not needing the admin token, such as logging in
+func withoutAuth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutAuthKey{}, true)
+}
+
+type withTokenKey struct{}
+
+// WithToken makes requests made with ctx use token instead of the configured admin
+// token, so a token can be checked before it is stored
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, withTokenKey{}, token)
+}
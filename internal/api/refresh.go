@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"crm-admin/internal/config"
+	"crm-admin/internal/credentials"
+	"crm-admin/internal/models"
+)
+
+// refreshMu makes concurrent requests that were all rejected share a single refresh
+var refreshMu sync.Mutex
+
+// refreshToken exchanges the stored refresh token of the active profile for a new
+// token, stores it and returns it. rejected is the token the backend refused; if the
+// stored token has changed since, another request already refreshed it.
+func refreshToken(ctx context.Context, next Doer, baseURL, rejected string) (string, error) {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	profile := config.ActiveProfileName()
+	cred, err := credentials.Load(profile)
+	if err != nil {
+		return "", err
+	}
+	if cred == nil || cred.RefreshToken == "" {
+		return "", fmt.Errorf("no refresh token stored for profile '%s'", profile)
+	}
+	if cred.Token != rejected {
+		return cred.Token, nil
+	}
+
+	data, err := json.Marshal(models.RefreshRequest{RefreshToken: cred.RefreshToken})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	endpoint := config.GetRefreshEndpoint()
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = baseURL + endpoint
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := next.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+	defer resp.Body.Close()
+	if !isSuccess(resp) {
+		return "", newError(resp)
+	}
+
+	var token models.TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("no token in the refresh response")
+	}
+
+	updated := *cred
+	updated.Token = token.Token
+	if token.RefreshToken != "" {
+		updated.RefreshToken = token.RefreshToken
+	}
+	if err := credentials.Update(profile, &updated); err != nil {
+		return "", err
+	}
+	return updated.Token, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"crm-admin/internal/credentials"
+	"crm-admin/internal/models"
+)
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+		refreshFails bool
+		wantErr      bool
+		wantToken    string
+	}{
+		{name: "refreshed", refreshToken: "refresh-1", wantToken: "new-token"},
+		{name: "no refresh token", wantErr: true, wantToken: "old-token"},
+		{name: "refresh rejected", refreshToken: "refresh-1", refreshFails: true, wantErr: true, wantToken: "old-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			t.Setenv("CRM_CONFIG", "")
+			t.Setenv("CRM_PROFILE", "")
+			t.Setenv("CRM_ADMIN_API_KEY", "")
+			t.Setenv("CRM_REFRESH_ENDPOINT", "/auth/refresh")
+
+			var refreshes atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/auth/refresh" {
+					refreshes.Add(1)
+					var body models.RefreshRequest
+					json.NewDecoder(r.Body).Decode(&body)
+					if tt.refreshFails || body.RefreshToken != tt.refreshToken {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					json.NewEncoder(w).Encode(models.TokenResponse{AccessToken: "new-token", RefreshToken: "refresh-2"})
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer new-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				json.NewEncoder(w).Encode([]models.User{{ID: "u1", Username: "alice"}})
+			}))
+			defer server.Close()
+
+			cred := &credentials.Credential{Token: "old-token", RefreshToken: tt.refreshToken}
+			if err := credentials.Save("default", cred, credentials.FileKeyring, ""); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { credentials.Delete("default") })
+
+			client := &Client{transport: http.DefaultClient, baseURL: server.URL}
+			users, err := client.ListUsers(t.Context())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", users)
+				}
+				if !IsUnauthorized(err) {
+					t.Fatalf("got %v, want the original 401", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if len(users) != 1 {
+				t.Fatalf("got %v, want one user", users)
+			}
+
+			stored, err := credentials.Load("default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Token != tt.wantToken {
+				t.Fatalf("stored token = %q, want %q", stored.Token, tt.wantToken)
+			}
+			if tt.wantToken == "new-token" && stored.RefreshToken != "refresh-2" {
+				t.Fatalf("stored refresh token = %q, want the rotated one", stored.RefreshToken)
+			}
+			if tt.refreshToken == "" && refreshes.Load() != 0 {
+				t.Fatal("refresh endpoint called without a refresh token")
+			}
+		})
+	}
+}
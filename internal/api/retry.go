@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// retryPolicy controls how failed requests are retried
+type retryPolicy struct {
+	maxRetries int           // retries after the first attempt
+	deadline   time.Duration // total time for all attempts; 0 means no limit
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// retryMiddleware retries connection errors, 429 and 5xx responses for idempotent
+// requests. The last response or error is returned when retries run out, the
+// server's Retry-After is longer than maxDelay or the request's context is cancelled.
+func retryMiddleware(policy retryPolicy) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return policy.do(next, req)
+		})
+	}
+}
+
+func (p retryPolicy) do(next Doer, req *http.Request) (*http.Response, error) {
+	if p.deadline <= 0 {
+		return p.attempts(next, req)
+	}
+
+	// The deadline also cuts off an attempt in flight, not only the waits between them.
+	// It is released once the caller is done with the response body.
+	ctx, cancel := context.WithTimeout(req.Context(), p.deadline)
+	resp, err := p.attempts(next, req.WithContext(ctx))
+	if resp == nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, err
+}
+
+func (p retryPolicy) attempts(next Doer, req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req)
+	deadline, hasDeadline := req.Context().Deadline()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := rewindBody(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := next.Do(req)
+
+		if !retryable || attempt >= p.maxRetries || !shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		// A server asking for a longer wait than backoff ever takes gets its answer
+		// returned, with the hint, instead of the CLI sleeping for it
+		wait := p.backoff(attempt, resp)
+		if wait > p.maxDelay || hasDeadline && time.Now().Add(wait).After(deadline) {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// cancelOnClose releases a request's context when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// isIdempotent reports whether a request may be sent more than once. POST requests
+// qualify only when they carry an idempotency key.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return req.Header.Get(idempotencyKeyHeader) != ""
+	default:
+		return false
+	}
+}
+
+// shouldRetry reports whether the outcome of an attempt is worth retrying.
+// Nothing is retried once the caller has given up on the request.
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, errNoInteraction)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the delay before the next attempt: the server's Retry-After when
+// given, otherwise exponential backoff with jitter up to maxDelay
+func (p retryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	delay := float64(p.baseDelay) * math.Pow(2, float64(attempt))
+	if delay > float64(p.maxDelay) {
+		delay = float64(p.maxDelay)
+	}
+
+	// Equal jitter: half of the delay is fixed, the other half random
+	half := time.Duration(delay / 2)
+	return half + time.Duration(mathrand.Int64N(int64(half)+1))
+}
+
+// parseRetryAfter understands both forms of the Retry-After header: seconds and an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// rewindBody resets the request body so the request can be sent again
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	req.Body = body
+	return nil
+}
+
+// newIdempotencyKey returns a random key identifying one logical POST request
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusDoer answers with the given status codes in turn, repeating the last one
+func statusDoer(attempts *atomic.Int32, codes ...int) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		n := int(attempts.Add(1)) - 1
+		code := codes[min(n, len(codes)-1)]
+		return &http.Response{StatusCode: code, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("body"))}, nil
+	})
+}
+
+func fastPolicy() retryPolicy {
+	return retryPolicy{maxRetries: 3, baseDelay: time.Millisecond, maxDelay: 2 * time.Millisecond}
+}
+
+func TestRetryStatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		key          bool
+		codes        []int
+		wantAttempts int32
+		wantStatus   int
+	}{
+		{name: "success", method: http.MethodGet, codes: []int{200}, wantAttempts: 1, wantStatus: 200},
+		{name: "429 then success", method: http.MethodGet, codes: []int{429, 200}, wantAttempts: 2, wantStatus: 200},
+		{name: "5xx then success", method: http.MethodPut, codes: []int{503, 500, 200}, wantAttempts: 3, wantStatus: 200},
+		{name: "5xx until retries run out", method: http.MethodDelete, codes: []int{502}, wantAttempts: 4, wantStatus: 502},
+		{name: "4xx is not retried", method: http.MethodGet, codes: []int{404, 200}, wantAttempts: 1, wantStatus: 404},
+		{name: "POST without key is not retried", method: http.MethodPost, codes: []int{503, 200}, wantAttempts: 1, wantStatus: 503},
+		{name: "POST with key is retried", method: http.MethodPost, key: true, codes: []int{503, 200}, wantAttempts: 2, wantStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			req, _ := http.NewRequestWithContext(t.Context(), tt.method, "http://example.invalid/api", nil)
+			if tt.key {
+				req.Header.Set(idempotencyKeyHeader, newIdempotencyKey())
+			}
+
+			resp, err := fastPolicy().do(statusDoer(&attempts, tt.codes...), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || attempts.Load() != tt.wantAttempts {
+				t.Fatalf("got status %d after %d attempts, want %d after %d", resp.StatusCode, attempts.Load(), tt.wantStatus, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryConnectionErrors(t *testing.T) {
+	var attempts atomic.Int32
+	failing := DoerFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		return nil, errors.New("connection refused")
+	})
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.invalid/api", nil)
+
+	if _, err := fastPolicy().do(failing, req); err == nil || attempts.Load() != 4 {
+		t.Fatalf("got %v after %d attempts, want an error after 4", err, attempts.Load())
+	}
+}
+
+func TestRetryDeadlineCutsOffAttempt(t *testing.T) {
+	hanging := DoerFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("attempt was not cut off")
+		}
+	})
+	policy := fastPolicy()
+	policy.deadline = 50 * time.Millisecond
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.invalid/api", nil)
+
+	start := time.Now()
+	_, err := policy.do(hanging, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the deadline to be exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request took %v despite a 50ms deadline", elapsed)
+	}
+}
+
+func TestRetryDeadlineKeepsBodyReadable(t *testing.T) {
+	var attempts atomic.Int32
+	policy := fastPolicy()
+	policy.deadline = time.Minute
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.invalid/api", nil)
+
+	resp, err := policy.do(statusDoer(&attempts, 200), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "body" {
+		t.Fatalf("body = %q, %v", body, err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		atLeast    time.Duration
+		atMost     time.Duration
+	}{
+		{name: "first retry", attempt: 0, atLeast: 50 * time.Millisecond, atMost: 100 * time.Millisecond},
+		{name: "grows exponentially", attempt: 3, atLeast: 400 * time.Millisecond, atMost: 800 * time.Millisecond},
+		{name: "capped", attempt: 30, atLeast: 500 * time.Millisecond, atMost: time.Second},
+		{name: "Retry-After seconds", attempt: 0, retryAfter: "7", atLeast: 7 * time.Second, atMost: 7 * time.Second},
+		{name: "Retry-After date", attempt: 0, retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), atLeast: 58 * time.Minute, atMost: time.Hour},
+		{name: "invalid Retry-After", attempt: 0, retryAfter: "soon", atLeast: 50 * time.Millisecond, atMost: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: make(http.Header)}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			for range 20 {
+				if wait := policy.backoff(tt.attempt, resp); wait < tt.atLeast || wait > tt.atMost {
+					t.Fatalf("backoff = %v, want between %v and %v", wait, tt.atLeast, tt.atMost)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	var times []time.Time
+	doer := DoerFunc(func(req *http.Request) (*http.Response, error) {
+		times = append(times, time.Now())
+		if attempts.Add(1) == 1 {
+			header := make(http.Header)
+			header.Set("Retry-After", "1")
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.invalid/api", nil)
+	policy := fastPolicy()
+	policy.maxDelay = 2 * time.Second
+
+	resp, err := policy.do(doer, req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("got %v, %v", resp, err)
+	}
+	if gap := times[1].Sub(times[0]); gap < time.Second {
+		t.Fatalf("retried after %v, want the server's 1s Retry-After", gap)
+	}
+
+	// A Retry-After beyond the deadline gives up instead of waiting
+	attempts.Store(0)
+	times = nil
+	policy.deadline = 100 * time.Millisecond
+	resp, err = policy.do(doer, req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempts.Load() != 1 {
+		t.Fatalf("got %v, %v after %d attempts, want the 429 after 1", resp, err, attempts.Load())
+	}
+	resp.Body.Close()
+}
+
+func TestRetryGivesUpOnLongRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	doer := DoerFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		header := make(http.Header)
+		header.Set("Retry-After", "86400")
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.invalid/api", nil)
+
+	start := time.Now()
+	resp, err := fastPolicy().do(doer, req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempts.Load() != 1 {
+		t.Fatalf("got %v, %v after %d attempts, want the 429 after 1", resp, err, attempts.Load())
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("waited %v for a day-long Retry-After", elapsed)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+
+	"crm-admin/internal/models"
+)
+
+// CRMService covers every user, contact and note operation the CLI performs.
+// *Client implements it against the backend; internal/fake implements it in memory.
+//
+// Methods that take a userID fall back to the selected user when it is empty.
+type CRMService interface {
+	CreateUser(ctx context.Context, username, password string) (*models.User, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	RenameUser(ctx context.Context, userID, username string) (*models.User, error)
+	SetUserPassword(ctx context.Context, userID, password string) error
+	DeleteUser(ctx context.Context, userID string) error
+
+	CreateContact(ctx context.Context, name, userID string, company, phoneNumber, contactEmail *string) (*models.Contact, error)
+	ListContacts(ctx context.Context, userID string) ([]models.Contact, error)
+	GetContact(ctx context.Context, userID string, contactID int) (*models.Contact, error)
+	UpdateContact(ctx context.Context, userID string, contactID int, name string, company, phoneNumber, contactEmail *string) (*models.Contact, error)
+	DeleteContact(ctx context.Context, userID string, contactID int) error
+
+	CreateNote(ctx context.Context, title, description string, contactIDs []int, userID string) (*models.Note, error)
+	ListNotesForUser(ctx context.Context, userID string) ([]models.Note, error)
+	ListNotesForContact(ctx context.Context, userID string, contactID int) ([]models.Note, error)
+	GetNote(ctx context.Context, userID string, noteID int) (*models.Note, error)
+	UpdateNote(ctx context.Context, userID string, noteID int, title, description string, contactIDs []int) (*models.Note, error)
+	DeleteNote(ctx context.Context, userID string, noteID int) error
+}
+
+var _ CRMService = (*Client)(nil)
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxTracedBody limits how much of a request or response body is logged
+const maxTracedBody = 16 * 1024
+
+const maskedValue = "****"
+
+// sensitiveFields are JSON keys whose values are masked in logged bodies. A key
+// matches when it contains one of them, ignoring case.
+var sensitiveFields = []string{"password", "token", "secret"}
+
+// TraceMiddleware logs every HTTP attempt: method, URL, status and latency at info
+// level, and the headers and bodies at debug level. The Authorization header and
+// password or token fields in JSON bodies are masked.
+func TraceMiddleware(logger *slog.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			debug := logger.Enabled(ctx, slog.LevelDebug)
+
+			var reqBody []byte
+			if debug {
+				var err error
+				if reqBody, err = readRequestBody(req); err != nil {
+					return nil, err
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.Do(req)
+			latency := time.Since(start)
+
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelWarn, "HTTP request failed",
+					slog.String("method", req.Method),
+					slog.String("url", req.URL.Redacted()),
+					slog.Duration("latency", latency),
+					slog.String("error", err.Error()))
+				return nil, err
+			}
+
+			logger.LogAttrs(ctx, slog.LevelInfo, "HTTP request",
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.Redacted()),
+				slog.Int("status", resp.StatusCode),
+				slog.Duration("latency", latency))
+
+			if debug {
+				respBody, readErr := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				resp.Body = io.NopCloser(bytes.NewReader(respBody))
+				if readErr != nil {
+					return nil, readErr
+				}
+
+				logger.LogAttrs(ctx, slog.LevelDebug, "HTTP exchange",
+					slog.String("method", req.Method),
+					slog.String("url", req.URL.Redacted()),
+					slog.Any("request_headers", maskHeader(req.Header)),
+					slog.String("request_body", maskBody(reqBody)),
+					slog.Any("response_headers", maskHeader(resp.Header)),
+					slog.String("response_body", maskBody(respBody)))
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// maskHeader hides credentials and cookies but keeps the authentication scheme visible
+func maskHeader(header http.Header) http.Header {
+	masked := header.Clone()
+	for _, name := range redactedHeaders {
+		value := masked.Get(name)
+		if value == "" {
+			continue
+		}
+		if scheme, _, ok := strings.Cut(value, " "); ok && strings.HasSuffix(name, "Authorization") {
+			masked.Set(name, scheme+" "+maskedValue)
+		} else {
+			masked.Set(name, maskedValue)
+		}
+	}
+	return masked
+}
+
+// maskBody masks sensitive fields of a JSON body and truncates long bodies.
+// Bodies that aren't JSON are logged as they are.
+func maskBody(body []byte) string {
+	text := string(maskJSON(body))
+	if len(text) > maxTracedBody {
+		text = text[:maxTracedBody] + "... (truncated)"
+	}
+	return text
+}
+
+// maskJSON masks sensitive fields of a JSON body. Bodies that aren't JSON are
+// returned as they are.
+func maskJSON(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if decoder.Decode(&value) != nil {
+		return body
+	}
+	masked, err := json.Marshal(maskValue(value))
+	if err != nil {
+		return body
+	}
+	return masked
+}
+
+func maskValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitiveField(key) {
+				v[key] = maskedValue
+			} else {
+				v[key] = maskValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = maskValue(item)
+		}
+	}
+	return value
+}
+
+func isSensitiveField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
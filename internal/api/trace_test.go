@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaskBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "empty", body: "", want: ""},
+		{name: "user request", body: `{"username":"alice","password":"hunter2"}`, want: `{"password":"****","username":"alice"}`},
+		{name: "nested token", body: `[{"auth":{"refreshToken":"abc"}}]`, want: `[{"auth":{"refreshToken":"****"}}]`},
+		{name: "not JSON", body: "password=hunter2", want: "password=hunter2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskBody([]byte(tt.body)); got != tt.want {
+				t.Fatalf("maskBody(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceMiddlewareMasksSecrets(t *testing.T) {
+	t.Setenv("CRM_ADMIN_API_KEY", "secret-token")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("id", "u1")
+		w.Header().Add("Set-Cookie", "session=cookie-secret; Path=/; HttpOnly")
+		w.Header().Add("Set-Cookie", "csrf=other-cookie-secret")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client := &Client{transport: http.DefaultClient, baseURL: server.URL}
+	client.Use(TraceMiddleware(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	if _, err := client.CreateUser(t.Context(), "alice", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	output := logs.String()
+	for _, secret := range []string{"secret-token", "hunter2", "cookie-secret"} {
+		if strings.Contains(output, secret) {
+			t.Fatalf("log contains %q:\n%s", secret, output)
+		}
+	}
+	for _, want := range []string{"method=POST", "status=201", "Bearer ****", server.URL + "/api/user"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("log is missing %q:\n%s", want, output)
+		}
+	}
+}
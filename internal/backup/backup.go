@@ -0,0 +1,384 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/export"
+)
+
+// SchemaVersion is the layout version of backup archives. Restore refuses archives
+// with a different version.
+const SchemaVersion = 1
+
+const manifestName = "manifest.json"
+
+// Manifest describes a backup archive. It is the first file of the archive.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	Source        string            `json:"source,omitempty"` // base URL of the backend that was backed up
+	Users         []UserEntry       `json:"users"`
+	Checksums     map[string]string `json:"checksums"` // SHA-256 of every other file, by name
+}
+
+// UserEntry lists one user of a backup and the file holding their data
+type UserEntry struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	File     string `json:"file"`
+	Contacts int    `json:"contacts"`
+	Notes    int    `json:"notes"`
+}
+
+// Backup is the content of a backup archive: one export dataset per user
+type Backup struct {
+	Manifest Manifest
+	Datasets []*export.Dataset // in the order of Manifest.Users
+}
+
+// Create backs up every user with their contacts and notes, fetching up to
+// concurrency users at the same time
+func Create(ctx context.Context, service api.CRMService, concurrency int) (*Backup, error) {
+	users, err := service.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	datasets := make([]*export.Dataset, len(users))
+	err = forEach(ctx, len(users), concurrency, func(ctx context.Context, i int) error {
+		dataset, err := export.FetchFor(ctx, service, users[i])
+		datasets[i] = dataset
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{
+		Manifest: Manifest{SchemaVersion: SchemaVersion, CreatedAt: time.Now().UTC().Truncate(time.Second)},
+		Datasets: datasets,
+	}
+	for _, dataset := range datasets {
+		backup.Manifest.Users = append(backup.Manifest.Users, UserEntry{
+			ID:       dataset.User.ID,
+			Username: dataset.User.Username,
+			File:     path.Join("users", dataset.User.ID+".json"),
+			Contacts: len(dataset.Contacts),
+			Notes:    len(dataset.Notes),
+		})
+	}
+	if backup.Manifest.Users == nil {
+		backup.Manifest.Users = []UserEntry{}
+	}
+	return backup, nil
+}
+
+// Write writes the backup as a tar.gz archive: manifest.json followed by one
+// users/<id>.json file per user
+func (b *Backup) Write(w io.Writer) error {
+	files := make(map[string][]byte)
+	b.Manifest.Checksums = make(map[string]string)
+	for i, dataset := range b.Datasets {
+		var buf bytes.Buffer
+		if err := dataset.WriteJSON(&buf); err != nil {
+			return err
+		}
+		name := b.Manifest.Users[i].File
+		files[name] = buf.Bytes()
+		b.Manifest.Checksums[name] = checksum(buf.Bytes())
+	}
+
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	compressor := gzip.NewWriter(w)
+	archive := tar.NewWriter(compressor)
+	add := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: b.Manifest.CreatedAt}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+		if _, err := archive.Write(data); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+		return nil
+	}
+
+	if err := add(manifestName, manifest); err != nil {
+		return err
+	}
+	for _, user := range b.Manifest.Users {
+		if err := add(user.File, files[user.File]); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// Read reads a backup archive, checking its schema version and the checksum of
+// every file listed in the manifest
+func Read(r io.Reader) (*Backup, error) {
+	decompressor, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer decompressor.Close()
+
+	files := make(map[string][]byte)
+	archive := tar.NewReader(decompressor)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from backup: %w", header.Name, err)
+		}
+		files[header.Name] = data
+	}
+
+	data, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("the backup has no %s", manifestName)
+	}
+	backup := &Backup{}
+	if err := json.Unmarshal(data, &backup.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestName, err)
+	}
+	if version := backup.Manifest.SchemaVersion; version != SchemaVersion {
+		return nil, fmt.Errorf("the backup has schema version %d, this version of crm-admin supports %d", version, SchemaVersion)
+	}
+
+	for _, user := range backup.Manifest.Users {
+		data, ok := files[user.File]
+		if !ok {
+			return nil, fmt.Errorf("the backup is missing %s", user.File)
+		}
+		if want := backup.Manifest.Checksums[user.File]; checksum(data) != want {
+			return nil, fmt.Errorf("checksum mismatch for %s: the backup is corrupt", user.File)
+		}
+
+		var dataset export.Dataset
+		if err := json.Unmarshal(data, &dataset); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", user.File, err)
+		}
+		if dataset.SchemaVersion != export.SchemaVersion {
+			return nil, fmt.Errorf("%s has schema version %d, this version of crm-admin supports %d", user.File, dataset.SchemaVersion, export.SchemaVersion)
+		}
+		dataset.Sort()
+		backup.Datasets = append(backup.Datasets, &dataset)
+	}
+	return backup, nil
+}
+
+// RestoreOptions control how a backup is restored
+type RestoreOptions struct {
+	// Password is given to every restored user, since backups can't contain
+	// passwords. When empty, each user gets a random password.
+	Password string
+
+	// Concurrency is the number of users restored at the same time
+	Concurrency int
+}
+
+// Report maps the IDs of a backup to the IDs the backend assigned when restoring it
+type Report struct {
+	Source     string       `json:"source,omitempty"`
+	RestoredAt time.Time    `json:"restoredAt"`
+	Users      []UserReport `json:"users"`
+}
+
+// UserReport is the part of a Report about one user. Contacts and Notes map old IDs
+// to new ones. DroppedContacts maps old note IDs to the contacts they linked that
+// aren't part of the backup, which were left out of the restored notes.
+type UserReport struct {
+	Username        string        `json:"username"`
+	OldID           string        `json:"oldId"`
+	NewID           string        `json:"newId,omitempty"`
+	Password        string        `json:"password,omitempty"`
+	Contacts        map[int]int   `json:"contacts"`
+	Notes           map[int]int   `json:"notes"`
+	DroppedContacts map[int][]int `json:"droppedContacts,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// Restore recreates every user of the backup with their contacts and notes. The
+// contact IDs of notes are rewritten to the IDs of the recreated contacts. The
+// report covers everything that was restored, also when an error is returned.
+func Restore(ctx context.Context, service api.CRMService, backup *Backup, options RestoreOptions) (*Report, error) {
+	report := &Report{
+		Source:     backup.Manifest.Source,
+		RestoredAt: time.Now().UTC().Truncate(time.Second),
+		Users:      make([]UserReport, len(backup.Datasets)),
+	}
+	for i, dataset := range backup.Datasets {
+		report.Users[i] = UserReport{
+			Username: dataset.User.Username,
+			OldID:    dataset.User.ID,
+			Contacts: make(map[int]int),
+			Notes:    make(map[int]int),
+		}
+	}
+
+	err := forEach(ctx, len(backup.Datasets), options.Concurrency, func(ctx context.Context, i int) error {
+		user := &report.Users[i]
+		err := restoreUser(ctx, service, backup.Datasets[i], options.Password, user)
+		if err != nil {
+			user.Error = err.Error()
+		}
+		return err
+	})
+
+	for i := range report.Users {
+		if user := &report.Users[i]; user.NewID == "" && user.Error == "" {
+			user.Error = "not restored because of an earlier error"
+		}
+	}
+	return report, err
+}
+
+func restoreUser(ctx context.Context, service api.CRMService, dataset *export.Dataset, password string, report *UserReport) error {
+	if password == "" {
+		password = randomPassword()
+		report.Password = password
+	}
+	user, err := service.CreateUser(ctx, dataset.User.Username, password)
+	if err != nil {
+		return fmt.Errorf("failed to create user '%s': %w", dataset.User.Username, err)
+	}
+	report.NewID = user.ID
+
+	for _, contact := range dataset.Contacts {
+		created, err := service.CreateContact(ctx, contact.Name, user.ID, contact.Company, contact.PhoneNumber, contact.ContactEmail)
+		if err != nil {
+			return fmt.Errorf("failed to restore contact %d of user '%s': %w", contact.ID, dataset.User.Username, err)
+		}
+		report.Contacts[contact.ID] = created.ID
+	}
+
+	for _, note := range dataset.Notes {
+		// A dangling contact ID shouldn't cost the user the rest of their data
+		contactIDs, missing := RemapContactIDs(note.ContactIDs, report.Contacts)
+		if len(missing) > 0 {
+			if report.DroppedContacts == nil {
+				report.DroppedContacts = make(map[int][]int)
+			}
+			report.DroppedContacts[note.ID] = missing
+		}
+		created, err := service.CreateNote(ctx, note.Title, stringValue(note.Description), contactIDs, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to restore note %d of user '%s': %w", note.ID, dataset.User.Username, err)
+		}
+		report.Notes[note.ID] = created.ID
+	}
+	return nil
+}
+
+// RemapContactIDs rewrites contact IDs with mapping. IDs it doesn't cover are left
+// out and returned as missing.
+func RemapContactIDs(contactIDs []int, mapping map[int]int) (remapped, missing []int) {
+	remapped = make([]int, 0, len(contactIDs))
+	for _, id := range contactIDs {
+		newID, ok := mapping[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		remapped = append(remapped, newID)
+	}
+	return remapped, missing
+}
+
+// forEach calls fn for 0..n-1 with up to concurrency calls running at the same time.
+// The first error cancels the calls that haven't started and is returned.
+func forEach(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	indexes := make(chan int)
+	for range min(concurrency, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, i); err != nil {
+					mu.Lock()
+					if firstErr == nil || errors.Is(firstErr, context.Canceled) {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// randomPassword returns a password for a restored user
+func randomPassword() string {
+	return rand.Text()
+}
@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/export"
+	"crm-admin/internal/fake"
+	"crm-admin/internal/models"
+)
+
+// newSource returns a backend whose contact IDs have gaps, so restoring it onto a
+// fresh backend assigns different IDs
+func newSource(t *testing.T) *fake.Service {
+	t.Helper()
+	ctx := t.Context()
+	service := fake.New()
+	for _, username := range []string{"alice", "bob"} {
+		user, err := service.CreateUser(ctx, username, "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		gone, _ := service.CreateContact(ctx, "Gone", user.ID, nil, nil, nil)
+		jane, _ := service.CreateContact(ctx, "Jane of "+username, user.ID, nil, nil, nil)
+		john, _ := service.CreateContact(ctx, "John of "+username, user.ID, nil, nil, nil)
+		service.DeleteContact(ctx, user.ID, gone.ID)
+		if _, err := service.CreateNote(ctx, "Meeting", "with both", []int{john.ID, jane.ID}, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		service.CreateNote(ctx, "Unlinked", "", nil, user.ID)
+	}
+	return service
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := t.Context()
+	source := newSource(t)
+
+	created, err := Create(ctx, source, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := created.Write(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Datasets) != 2 || read.Manifest.Users[0].Contacts != 2 || read.Manifest.Users[0].Notes != 2 {
+		t.Fatalf("unexpected manifest: %+v", read.Manifest)
+	}
+
+	target := fake.New()
+	report, err := Restore(ctx, target, read, RestoreOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, user := range report.Users {
+		if user.Password == "" || user.NewID == "" {
+			t.Fatalf("user %s not restored: %+v", user.Username, user)
+		}
+		notes, err := target.ListNotesForUser(ctx, user.NewID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contacts, _ := target.ListContacts(ctx, user.NewID)
+		names := make(map[int]string)
+		for _, contact := range contacts {
+			names[contact.ID] = contact.Name
+		}
+
+		var linked []string
+		for _, note := range notes {
+			for _, id := range note.ContactIDs {
+				linked = append(linked, names[id])
+			}
+		}
+		slices.Sort(linked)
+		want := []string{"Jane of " + read.Datasets[i].User.Username, "John of " + read.Datasets[i].User.Username}
+		if !slices.Equal(linked, want) {
+			t.Fatalf("notes of %s link %v, want %v", user.Username, linked, want)
+		}
+		for oldID, newID := range user.Contacts {
+			if oldID == newID {
+				t.Fatalf("contact %d kept its ID; the test data should force new IDs", oldID)
+			}
+		}
+	}
+}
+
+func TestRestoreDropsDanglingContacts(t *testing.T) {
+	ctx := t.Context()
+	archive := &Backup{Datasets: []*export.Dataset{{
+		User:     models.User{ID: "u1", Username: "alice"},
+		Contacts: []models.Contact{{ID: 5, UserID: "u1", Name: "Jane"}},
+		Notes: []models.Note{
+			{ID: 7, UserID: "u1", Title: "Call", ContactIDs: []int{5, 99}},
+			{ID: 8, UserID: "u1", Title: "Lunch"},
+		},
+	}}}
+
+	target := fake.New()
+	report, err := Restore(ctx, target, archive, RestoreOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := report.Users[0]
+	if user.Error != "" || len(user.Notes) != 2 {
+		t.Fatalf("user not fully restored: %+v", user)
+	}
+	if dropped := user.DroppedContacts[7]; !slices.Equal(dropped, []int{99}) || len(user.DroppedContacts) != 1 {
+		t.Fatalf("got dropped contacts %v, want 99 of note 7", user.DroppedContacts)
+	}
+	notes, _ := target.ListNotesForUser(ctx, user.NewID)
+	for _, note := range notes {
+		if note.ID == user.Notes[7] && !slices.Equal(note.ContactIDs, []int{user.Contacts[5]}) {
+			t.Fatalf("note links %v, want only the restored contact %d", note.ContactIDs, user.Contacts[5])
+		}
+	}
+}
+
+func TestReadRejectsBadArchives(t *testing.T) {
+	created, err := Create(t.Context(), newSource(t), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := created.Write(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(name string, data []byte) []byte
+		wantErr string
+	}{
+		{
+			name: "corrupt user file",
+			modify: func(name string, data []byte) []byte {
+				if strings.HasPrefix(name, "users/") {
+					return bytes.Replace(data, []byte("Jane"), []byte("Joan"), 1)
+				}
+				return data
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name: "newer schema",
+			modify: func(name string, data []byte) []byte {
+				if name == manifestName {
+					return bytes.Replace(data, []byte(`"schemaVersion": 1`), []byte(`"schemaVersion": 2`), 1)
+				}
+				return data
+			},
+			wantErr: "schema version 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := rewrite(t, archive.Bytes(), tt.modify)
+			if _, err := Read(bytes.NewReader(modified)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Read(strings.NewReader("not an archive")); err == nil {
+		t.Fatal("expected an error for a file that isn't an archive")
+	}
+}
+
+// rewrite copies a tar.gz archive, passing the content of every file through modify
+func rewrite(t *testing.T, archive []byte, modify func(name string, data []byte) []byte) []byte {
+	t.Helper()
+	decompressor, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := tar.NewReader(decompressor)
+
+	var out bytes.Buffer
+	compressor := gzip.NewWriter(&out)
+	writer := tar.NewWriter(compressor)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(reader)
+		data = modify(header.Name, data)
+		header.Size = int64(len(data))
+		writer.WriteHeader(header)
+		writer.Write(data)
+	}
+	writer.Close()
+	compressor.Close()
+	return out.Bytes()
+}
@@ -1,22 +1,364 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"crm-admin/internal/fsutil"
+)
+
+const (
+	DefaultBaseURL     = "http://localhost:8082/api"
+	DefaultProfileName = "default"
+
+	DefaultRetries = 2
+	DefaultTimeout = 30 * time.Second
+
+	DefaultLoginEndpoint = "/api/auth/login"
+)
+
+// Profile holds the settings for one backend environment such as dev, staging or prod
+type Profile struct {
+	BaseURL     string `yaml:"base_url,omitempty"`
+	Token       string `yaml:"token,omitempty"`      // literal admin token (prefer token_env or token_file)
+	TokenEnv    string `yaml:"token_env,omitempty"`  // name of an environment variable holding the token
+	TokenFile   string `yaml:"token_file,omitempty"` // path of a file holding the token
+	DefaultUser string `yaml:"default_user,omitempty"`
+
+	// LoginEndpoint exchanges a username and password for a token in 'auth login'
+	LoginEndpoint string `yaml:"login_endpoint,omitempty"`
+
+	// RefreshEndpoint exchanges the refresh token from 'auth login' for a new token
+	// when the backend answers 401. Without it tokens are not refreshed.
+	RefreshEndpoint string `yaml:"refresh_endpoint,omitempty"`
+}
+
+// File is the on-disk configuration file
+type File struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// Overrides set from global command line flags. They take precedence over the
+// environment, which takes precedence over the active profile.
+var (
+	configPath      string
+	profileOverride string
+	baseURLOverride string
+
+	retriesOverride  = -1
+	timeoutOverride  time.Duration
+	deadlineOverride time.Duration
+	idempotencyKeys  bool
+	recordDir        string
+	replayDir        string
 )
 
-// GetBaseURL returns the backend URL from environment or default
+// loaded is the configuration file read by Load, or an empty file before Load is called
+var loaded = &File{}
+
+// SetPath overrides the location of the configuration file (--config)
+func SetPath(path string) {
+	configPath = path
+}
+
+// SetProfile overrides the active profile (--profile)
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// SetBaseURL overrides the backend URL (--backend-url)
+func SetBaseURL(url string) {
+	baseURLOverride = url
+}
+
+// SetRetries overrides how often failed idempotent requests are retried (--retries)
+func SetRetries(retries int) {
+	retriesOverride = retries
+}
+
+// SetTimeout overrides the timeout of a single HTTP attempt (--timeout)
+func SetTimeout(timeout time.Duration) {
+	timeoutOverride = timeout
+}
+
+// SetDeadline overrides the total time allowed for a request including retries (--deadline)
+func SetDeadline(deadline time.Duration) {
+	deadlineOverride = deadline
+}
+
+// SetIdempotencyKeys enables sending an Idempotency-Key header with POST requests (--idempotency-keys)
+func SetIdempotencyKeys(enabled bool) {
+	idempotencyKeys = enabled
+}
+
+// SetRecordDir records all HTTP interactions as cassette files in dir
+func SetRecordDir(dir string) {
+	recordDir = dir
+}
+
+// SetReplayDir answers HTTP requests from the cassette files in dir instead of the network
+func SetReplayDir(dir string) {
+	replayDir = dir
+}
+
+// Path returns the configuration file location: --config, $CRM_CONFIG,
+// $XDG_CONFIG_HOME/crm-admin/config.yaml or ~/.config/crm-admin/config.yaml
+func Path() string {
+	if configPath != "" {
+		return configPath
+	}
+	if path := os.Getenv("CRM_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(configDir(), "crm-admin", "config.yaml")
+}
+
+func configDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config")
+	}
+	return "."
+}
+
+// Load reads the configuration file. A missing file is treated as an empty configuration.
+func Load() error {
+	file, err := ReadFile()
+	if err != nil {
+		return err
+	}
+	loaded = file
+	return nil
+}
+
+// ReadFile reads the configuration file without making it the active configuration
+func ReadFile() (*File, error) {
+	file := &File{}
+
+	data, err := os.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", Path(), err)
+	}
+	return file, nil
+}
+
+// Save writes the configuration file atomically, readable only by the current user
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	data := buf.Bytes()
+
+	if err := fsutil.WriteFileAtomic(Path(), data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	loaded = f
+	return nil
+}
+
+// ProfileNames returns the configured profile names in alphabetical order
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveProfileName returns the selected profile: --profile, $CRM_PROFILE,
+// the current_profile from the config file, or "default"
+func ActiveProfileName() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if name := os.Getenv("CRM_PROFILE"); name != "" {
+		return name
+	}
+	if loaded.CurrentProfile != "" {
+		return loaded.CurrentProfile
+	}
+	return DefaultProfileName
+}
+
+// ActiveProfile returns the settings of the selected profile. A profile that is
+// not in the config file yields empty settings.
+func ActiveProfile() *Profile {
+	if profile, ok := loaded.Profiles[ActiveProfileName()]; ok && profile != nil {
+		return profile
+	}
+	return &Profile{}
+}
+
+// CheckActiveProfile returns an error when a profile was requested explicitly but does not exist
+func CheckActiveProfile() error {
+	name := ActiveProfileName()
+	if name == DefaultProfileName {
+		return nil
+	}
+	if _, ok := loaded.Profiles[name]; !ok {
+		return fmt.Errorf("profile '%s' not found in %s (create it with 'crm-admin profile add %s')", name, Path(), name)
+	}
+	return nil
+}
+
+// GetBaseURL returns the backend URL: --backend-url, $CRM_BACKEND_URL, the active profile or the default
 func GetBaseURL() string {
+	if baseURLOverride != "" {
+		return baseURLOverride
+	}
 	if url := os.Getenv("CRM_BACKEND_URL"); url != "" {
 		return url
 	}
-	return "http://localhost:8082/api"
+	if url := ActiveProfile().BaseURL; url != "" {
+		return url
+	}
+	return DefaultBaseURL
 }
 
-// GetAdminAPIKey returns the admin API key from environment or default
+// GetAdminToken returns the admin token from $CRM_ADMIN_API_KEY or the active profile's token source
 func GetAdminToken() string {
+	token, _ := AdminToken()
+	return token
+}
+
+// AdminToken returns the admin token from $CRM_ADMIN_API_KEY or the active profile's
+// token source, and describes where it was found, for example "env:CRM_ADMIN_API_KEY"
+func AdminToken() (token, source string) {
 	if key := os.Getenv("CRM_ADMIN_API_KEY"); key != "" {
-		return key
+		return key, "env:CRM_ADMIN_API_KEY"
+	}
+
+	profile := ActiveProfile()
+	if profile.TokenEnv != "" {
+		if key := os.Getenv(profile.TokenEnv); key != "" {
+			return key, "env:" + profile.TokenEnv
+		}
+	}
+	if profile.TokenFile != "" {
+		if data, err := os.ReadFile(expandHome(profile.TokenFile)); err == nil {
+			return strings.TrimSpace(string(data)), "file:" + profile.TokenFile
+		}
+	}
+	if profile.Token != "" {
+		return profile.Token, "config"
+	}
+	return "", ""
+}
+
+// GetLoginEndpoint returns the endpoint that exchanges credentials for a token
+func GetLoginEndpoint() string {
+	if endpoint := ActiveProfile().LoginEndpoint; endpoint != "" {
+		return endpoint
+	}
+	return DefaultLoginEndpoint
+}
+
+// GetRefreshEndpoint returns the endpoint that refreshes an expired token: $CRM_REFRESH_ENDPOINT
+// or the active profile's refresh_endpoint. Empty means tokens are not refreshed.
+func GetRefreshEndpoint() string {
+	if endpoint := os.Getenv("CRM_REFRESH_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	return ActiveProfile().RefreshEndpoint
+}
+
+// Dir returns the directory of the configuration file, where other per-user files such
+// as stored credentials are kept
+func Dir() string {
+	return filepath.Dir(Path())
+}
+
+// GetDefaultUser returns the user ID that the active profile selects when no user has been selected
+func GetDefaultUser() string {
+	return ActiveProfile().DefaultUser
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// GetRetries returns how often a failed idempotent request is retried: --retries, $CRM_RETRIES or 2
+func GetRetries() int {
+	if retriesOverride >= 0 {
+		return retriesOverride
+	}
+	if retries, err := strconv.Atoi(os.Getenv("CRM_RETRIES")); err == nil && retries >= 0 {
+		return retries
+	}
+	return DefaultRetries
+}
+
+// GetTimeout returns the timeout of a single HTTP attempt: --timeout, $CRM_TIMEOUT or 30s
+func GetTimeout() time.Duration {
+	if timeoutOverride > 0 {
+		return timeoutOverride
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("CRM_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultTimeout
+}
+
+// GetDeadline returns the total time allowed for a request including all retries:
+// --deadline or $CRM_DEADLINE. Zero means no limit beyond the per-attempt timeout.
+func GetDeadline() time.Duration {
+	if deadlineOverride > 0 {
+		return deadlineOverride
+	}
+	if deadline, err := time.ParseDuration(os.Getenv("CRM_DEADLINE")); err == nil && deadline > 0 {
+		return deadline
+	}
+	return 0
+}
+
+// UseIdempotencyKeys reports whether POST requests carry an Idempotency-Key header,
+// which makes them safe to retry
+func UseIdempotencyKeys() bool {
+	return idempotencyKeys || os.Getenv("CRM_IDEMPOTENCY_KEYS") == "true"
+}
+
+// GetRecordDir returns the directory HTTP interactions are recorded to: --record or $CRM_RECORD
+func GetRecordDir() string {
+	if recordDir != "" {
+		return recordDir
+	}
+	return os.Getenv("CRM_RECORD")
+}
+
+// GetReplayDir returns the directory HTTP interactions are replayed from: --replay or $CRM_REPLAY
+func GetReplayDir() string {
+	if replayDir != "" {
+		return replayDir
 	}
-	// Default key for development - NEVER use this in production!
-	return ""
+	return os.Getenv("CRM_REPLAY")
 }
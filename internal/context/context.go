@@ -4,24 +4,59 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"crm-admin/internal/config"
+	"crm-admin/internal/fsutil"
 	"crm-admin/internal/models"
 )
 
-const contextFileName = ".crm-context.json"
+// localContextFileName is the per-directory override written by 'user select --local'
+const localContextFileName = ".crm-context.json"
 
 type UserContext struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
+
+	// Local is set when the context comes from the per-directory override file
+	Local bool `json:"-"`
+
+	// FromProfile is set when no user was selected and the active profile's default user is used instead
+	FromProfile bool `json:"-"`
 }
 
-// getContextFilePath returns the path to the context file in the current directory
+// getContextFilePath returns the path of the selected user state file for the active profile,
+// $XDG_STATE_HOME/crm-admin/context/<profile>.json or ~/.local/state/crm-admin/context/<profile>.json
 func getContextFilePath() string {
-	return contextFileName
+	return filepath.Join(stateDir(), "crm-admin", "context", filepath.Base(config.ActiveProfileName())+".json")
+}
+
+// getLocalContextFilePath returns the path of the per-directory override in the current directory
+func getLocalContextFilePath() string {
+	return localContextFileName
+}
+
+func stateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state")
+	}
+	return "."
 }
 
-// SaveUserContext saves the selected user context to a file
+// SaveUserContext saves the selected user for the active profile
 func SaveUserContext(user *models.User) error {
+	return writeContextFile(getContextFilePath(), user)
+}
+
+// SaveLocalUserContext saves the selected user as an override for the current directory only
+func SaveLocalUserContext(user *models.User) error {
+	return writeContextFile(getLocalContextFilePath(), user)
+}
+
+func writeContextFile(path string, user *models.User) error {
 	context := UserContext{
 		UserID:   user.ID,
 		Username: user.Username,
@@ -32,8 +67,7 @@ func SaveUserContext(user *models.User) error {
 		return fmt.Errorf("failed to marshal context: %w", err)
 	}
 
-	contextPath := getContextFilePath()
-	err = os.WriteFile(contextPath, data, 0644)
+	err = fsutil.WriteFileAtomic(path, data, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to write context file: %w", err)
 	}
@@ -41,15 +75,30 @@ func SaveUserContext(user *models.User) error {
 	return nil
 }
 
-// LoadUserContext loads the selected user context from file
+// LoadUserContext loads the selected user context. A per-directory override wins over
+// the profile's state file, which wins over the active profile's default user.
 func LoadUserContext() (*UserContext, error) {
-	contextPath := getContextFilePath()
+	context, err := readContextFile(getLocalContextFilePath())
+	if err != nil || context != nil {
+		if context != nil {
+			context.Local = true
+		}
+		return context, err
+	}
 
-	if _, err := os.Stat(contextPath); os.IsNotExist(err) {
-		return nil, nil // No context file exists
+	context, err = readContextFile(getContextFilePath())
+	if err != nil || context != nil {
+		return context, err
 	}
 
-	data, err := os.ReadFile(contextPath)
+	return profileDefaultContext(), nil
+}
+
+func readContextFile(path string) (*UserContext, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil // No context file exists
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read context file: %w", err)
 	}
@@ -63,16 +112,35 @@ func LoadUserContext() (*UserContext, error) {
 	return &context, nil
 }
 
-// ClearUserContext removes the context file
+// ClearUserContext removes the selection that is currently in effect: the per-directory
+// override if there is one, otherwise the profile's state file
 func ClearUserContext() error {
-	contextPath := getContextFilePath()
+	if hasFile(getLocalContextFilePath()) {
+		return removeContextFile(getLocalContextFilePath())
+	}
+	return removeContextFile(getContextFilePath())
+}
 
-	if _, err := os.Stat(contextPath); os.IsNotExist(err) {
-		return nil // File doesn't exist, nothing to clear
+// ForgetUser removes every selection of userID, the per-directory override and the
+// profile's state file, and reports whether one was removed. Other selections are kept.
+func ForgetUser(userID string) (bool, error) {
+	removed := false
+	for _, path := range []string{getLocalContextFilePath(), getContextFilePath()} {
+		context, err := readContextFile(path)
+		if err != nil || context == nil || context.UserID != userID {
+			continue
+		}
+		if err := removeContextFile(path); err != nil {
+			return removed, err
+		}
+		removed = true
 	}
+	return removed, nil
+}
 
-	err := os.Remove(contextPath)
-	if err != nil {
+func removeContextFile(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove context file: %w", err)
 	}
 
@@ -81,11 +149,27 @@ func ClearUserContext() error {
 
 // HasUserContext checks if a user context exists
 func HasUserContext() bool {
-	contextPath := getContextFilePath()
-	_, err := os.Stat(contextPath)
+	return hasFile(getLocalContextFilePath()) || hasFile(getContextFilePath()) || profileDefaultContext() != nil
+}
+
+func hasFile(path string) bool {
+	_, err := os.Stat(path)
 	return err == nil
 }
 
+// profileDefaultContext returns a context for the active profile's default user, if it has one
+func profileDefaultContext() *UserContext {
+	userID := config.GetDefaultUser()
+	if userID == "" {
+		return nil
+	}
+	return &UserContext{
+		UserID:      userID,
+		Username:    userID,
+		FromProfile: true,
+	}
+}
+
 // GetContextualBaseURL returns the base URL with user context if available
 func GetContextualBaseURL(baseURL string) (string, *UserContext) {
 	context, err := LoadUserContext()
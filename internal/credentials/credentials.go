@@ -0,0 +1,318 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"crm-admin/internal/config"
+	"crm-admin/internal/fsutil"
+)
+
+// Keyring names how the key that encrypts a stored credential is obtained
+type Keyring string
+
+const (
+	// FileKeyring keeps a random key in a file next to the credentials, readable
+	// only by the current user. It works the same on every OS.
+	FileKeyring Keyring = "file"
+
+	// PassphraseKeyring derives the key from a passphrase, taken from
+	// $CRM_KEYRING_PASSPHRASE or asked for on the terminal
+	PassphraseKeyring Keyring = "passphrase"
+)
+
+const (
+	envelopeVersion  = 1
+	pbkdf2Iterations = 600_000
+	keyFileName      = "keyring.key"
+	keySize          = 32
+)
+
+// ErrNoCredential means no admin token is configured for the active profile
+var ErrNoCredential = errors.New("no admin credential configured")
+
+// Credential is what 'auth login' stores for a profile
+type Credential struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Username     string    `json:"username,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// envelope is the on-disk form of an encrypted credential
+type envelope struct {
+	Version    int     `json:"version"`
+	Keyring    Keyring `json:"keyring"`
+	Salt       []byte  `json:"salt,omitempty"`
+	Iterations int     `json:"iterations,omitempty"`
+	Nonce      []byte  `json:"nonce"`
+	Ciphertext []byte  `json:"ciphertext"`
+}
+
+// PassphrasePrompt asks for the keyring passphrase when $CRM_KEYRING_PASSPHRASE is not
+// set. It is nil when nobody can be asked.
+var PassphrasePrompt func() (string, error)
+
+// Decrypted credentials are cached with their key, so a passphrase is asked for at
+// most once per run and a refreshed token can be stored again
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]*cached)
+)
+
+type cached struct {
+	cred *Credential
+	env  envelope // keyring and key derivation parameters
+	key  []byte
+}
+
+// Path returns the file holding the stored credential of a profile
+func Path(profile string) string {
+	return filepath.Join(config.Dir(), "credentials", filepath.Base(profile)+".json")
+}
+
+// Save encrypts cred and stores it for profile. The passphrase is only used with
+// PassphraseKeyring.
+func Save(profile string, cred *Credential, keyring Keyring, passphrase string) error {
+	plaintext, err := json.Marshal(cred)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential: %w", err)
+	}
+
+	env := envelope{Version: envelopeVersion, Keyring: keyring}
+
+	var key []byte
+	switch keyring {
+	case FileKeyring:
+		key, err = fileKey(true)
+	case PassphraseKeyring:
+		if passphrase == "" {
+			return fmt.Errorf("a passphrase is required for the passphrase keyring")
+		}
+		env.Salt = make([]byte, 16)
+		rand.Read(env.Salt)
+		env.Iterations = pbkdf2Iterations
+		key, err = pbkdf2.Key(sha256.New, passphrase, env.Salt, env.Iterations, keySize)
+	default:
+		return fmt.Errorf("unknown keyring '%s' (use file or passphrase)", keyring)
+	}
+	if err != nil {
+		return err
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	return write(profile, &cached{cred: cred, env: env, key: key}, plaintext)
+}
+
+// Update stores a changed credential, such as a refreshed token, with the keyring and
+// key it was loaded with. The credential must have been loaded or saved before.
+func Update(profile string, cred *Credential) error {
+	plaintext, err := json.Marshal(cred)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential: %w", err)
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entry, ok := cache[profile]
+	if !ok {
+		return fmt.Errorf("the credential of profile '%s' has not been loaded", profile)
+	}
+	return write(profile, &cached{cred: cred, env: entry.env, key: entry.key}, plaintext)
+}
+
+// write encrypts plaintext with the entry's key, stores it and caches the entry.
+// Callers hold cacheMu.
+func write(profile string, entry *cached, plaintext []byte) error {
+	gcm, err := newGCM(entry.key)
+	if err != nil {
+		return err
+	}
+
+	env := entry.env
+	env.Nonce = make([]byte, gcm.NonceSize())
+	rand.Read(env.Nonce)
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, plaintext, []byte(profile))
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(Path(profile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write credential: %w", err)
+	}
+
+	cache[profile] = entry
+	return nil
+}
+
+// Load decrypts the stored credential of profile. It returns nil without an error
+// when the profile has no stored credential.
+func Load(profile string) (*Credential, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if entry, ok := cache[profile]; ok {
+		return entry.cred, nil
+	}
+
+	env, err := readEnvelope(profile)
+	if err != nil || env == nil {
+		return nil, err
+	}
+
+	var key []byte
+	switch env.Keyring {
+	case FileKeyring:
+		key, err = fileKey(false)
+	case PassphraseKeyring:
+		var passphrase string
+		passphrase, err = readPassphrase(profile)
+		if err == nil {
+			key, err = pbkdf2.Key(sha256.New, passphrase, env.Salt, env.Iterations, keySize)
+		}
+	default:
+		err = fmt.Errorf("credential for profile '%s' uses an unknown keyring '%s'", profile, env.Keyring)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, []byte(profile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the credential of profile '%s' (wrong passphrase or keyring key?)", profile)
+	}
+
+	var cred Credential
+	if err := json.Unmarshal(plaintext, &cred); err != nil {
+		return nil, fmt.Errorf("failed to parse credential: %w", err)
+	}
+	env.Nonce, env.Ciphertext = nil, nil
+	cache[profile] = &cached{cred: &cred, env: *env, key: key}
+	return &cred, nil
+}
+
+// StoredKeyring returns the keyring protecting the stored credential of profile,
+// or "" when there is none. It doesn't decrypt anything.
+func StoredKeyring(profile string) Keyring {
+	env, err := readEnvelope(profile)
+	if err != nil || env == nil {
+		return ""
+	}
+	return env.Keyring
+}
+
+// Delete removes the stored credential of profile and reports whether there was one
+func Delete(profile string) (bool, error) {
+	cacheMu.Lock()
+	delete(cache, profile)
+	cacheMu.Unlock()
+
+	err := os.Remove(Path(profile))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to remove credential: %w", err)
+	}
+	return true, nil
+}
+
+func readEnvelope(profile string) (*envelope, error) {
+	data, err := os.ReadFile(Path(profile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential: %w", err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse credential file %s: %w", Path(profile), err)
+	}
+	if env.Version != envelopeVersion {
+		return nil, fmt.Errorf("credential file %s has unsupported version %d", Path(profile), env.Version)
+	}
+	return &env, nil
+}
+
+// fileKey returns the file keyring's key, creating it first if create is set
+func fileKey(create bool) ([]byte, error) {
+	path := filepath.Join(config.Dir(), "credentials", keyFileName)
+
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("keyring key %s is corrupt", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read keyring key: %w", err)
+	}
+	if !create {
+		return nil, fmt.Errorf("keyring key %s is missing; run 'crm-admin auth login' again", path)
+	}
+
+	key = make([]byte, keySize)
+	rand.Read(key)
+	if err := fsutil.WriteFileAtomic(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write keyring key: %w", err)
+	}
+	return key, nil
+}
+
+func readPassphrase(profile string) (string, error) {
+	if passphrase := os.Getenv("CRM_KEYRING_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if PassphrasePrompt == nil {
+		return "", fmt.Errorf("the credential of profile '%s' is protected by a passphrase; set CRM_KEYRING_PASSPHRASE", profile)
+	}
+	return PassphrasePrompt()
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// Token returns the admin token for the active profile and where it comes from:
+// $CRM_ADMIN_API_KEY and the profile's token settings first, then 'auth login'
+func Token() (token, source string, err error) {
+	if token, source := config.AdminToken(); token != "" {
+		return token, source, nil
+	}
+
+	cred, err := Load(config.ActiveProfileName())
+	if err != nil {
+		return "", "", err
+	}
+	if cred == nil || cred.Token == "" {
+		return "", "", nil
+	}
+	return cred.Token, "login:" + string(StoredKeyring(config.ActiveProfileName())), nil
+}
@@ -0,0 +1,65 @@
+package credentials
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyring    Keyring
+		passphrase string
+		loadWith   string
+		wantErr    string
+	}{
+		{name: "file keyring", keyring: FileKeyring},
+		{name: "passphrase", keyring: PassphraseKeyring, passphrase: "correct horse", loadWith: "correct horse"},
+		{name: "wrong passphrase", keyring: PassphraseKeyring, passphrase: "correct horse", loadWith: "battery staple", wantErr: "failed to decrypt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			t.Setenv("CRM_CONFIG", "")
+			t.Setenv("CRM_KEYRING_PASSPHRASE", tt.loadWith)
+
+			if err := Save("dev", &Credential{Token: "token-value"}, tt.keyring, tt.passphrase); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(Path("dev"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "token-value") {
+				t.Fatalf("credential file contains the token in plain text:\n%s", data)
+			}
+
+			// Drop the cache so the file is decrypted
+			delete(cache, "dev")
+
+			cred, err := Load("dev")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cred.Token != "token-value" {
+				t.Fatalf("token = %q, want %q", cred.Token, "token-value")
+			}
+
+			// A credential is bound to its profile
+			if err := os.Rename(Path("dev"), Path("prod")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load("prod"); err == nil {
+				t.Fatal("loading a credential copied to another profile succeeded")
+			}
+		})
+	}
+}
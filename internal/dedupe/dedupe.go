@@ -0,0 +1,338 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/models"
+)
+
+// DefaultThreshold is the name similarity above which two contacts are candidates
+const DefaultThreshold = 0.85
+
+// minPhoneDigits is how many digits two phone numbers must share at the end to match,
+// so that "+1 555 0100" and "(555) 0100" are the same number
+const minPhoneDigits = 7
+
+// Group is a set of contacts that are probably the same person
+type Group struct {
+	Contacts []models.Contact `json:"contacts"` // sorted by ID
+	Reasons  []string         `json:"reasons"`
+	KeepID   int              `json:"keepId"` // the most complete contact, suggested to merge the others into
+}
+
+// DuplicateIDs returns the IDs of the group's contacts other than KeepID
+func (g Group) DuplicateIDs() []int {
+	var ids []int
+	for _, contact := range g.Contacts {
+		if contact.ID != g.KeepID {
+			ids = append(ids, contact.ID)
+		}
+	}
+	return ids
+}
+
+// Find groups contacts that share an email or phone number, or whose normalized
+// names are at least threshold similar (0 to 1) and whose companies don't differ
+func Find(contacts []models.Contact, threshold float64) []Group {
+	parent := make([]int, len(contacts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	reasons := make(map[[2]int][]string)
+	for i := range contacts {
+		for j := i + 1; j < len(contacts); j++ {
+			if why := compare(contacts[i], contacts[j], threshold); len(why) > 0 {
+				reasons[[2]int{i, j}] = why
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range contacts {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	var groups []Group
+	for _, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+		var group Group
+		for _, i := range indexes {
+			group.Contacts = append(group.Contacts, contacts[i])
+		}
+		sort.Slice(group.Contacts, func(a, b int) bool { return group.Contacts[a].ID < group.Contacts[b].ID })
+
+		for pair, why := range reasons {
+			if find(pair[0]) == find(indexes[0]) {
+				for _, reason := range why {
+					reason = fmt.Sprintf("%d and %d: %s", contacts[pair[0]].ID, contacts[pair[1]].ID, reason)
+					group.Reasons = append(group.Reasons, reason)
+				}
+			}
+		}
+		sort.Strings(group.Reasons)
+		group.KeepID = mostComplete(group.Contacts)
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(a, b int) bool { return groups[a].Contacts[0].ID < groups[b].Contacts[0].ID })
+	return groups
+}
+
+// compare returns why two contacts look like duplicates, or nil
+func compare(a, b models.Contact, threshold float64) []string {
+	var why []string
+	if email := NormalizeEmail(value(a.ContactEmail)); email != "" && email == NormalizeEmail(value(b.ContactEmail)) {
+		why = append(why, "same email")
+	}
+	if samePhone(value(a.PhoneNumber), value(b.PhoneNumber)) {
+		why = append(why, "same phone")
+	}
+
+	companyA, companyB := NormalizeName(value(a.Company)), NormalizeName(value(b.Company))
+	if companyA != "" && companyB != "" && companyA != companyB {
+		return why
+	}
+	if similarity := Similarity(NormalizeName(a.Name), NormalizeName(b.Name)); similarity >= threshold {
+		if similarity == 1 {
+			why = append(why, "same name")
+		} else {
+			why = append(why, fmt.Sprintf("similar names (%.2f)", similarity))
+		}
+	}
+	return why
+}
+
+// mostComplete returns the ID of the contact with the most fields set, the oldest on a tie
+func mostComplete(contacts []models.Contact) int {
+	best, bestScore := contacts[0].ID, -1
+	for _, contact := range contacts {
+		score := 0
+		for _, field := range []*string{contact.Company, contact.PhoneNumber, contact.ContactEmail} {
+			if value(field) != "" {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = contact.ID, score
+		}
+	}
+	return best
+}
+
+// NormalizeName lowercases a name, drops punctuation and collapses whitespace
+func NormalizeName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r) || r == '-' || r == '.' || r == ',':
+			return ' '
+		}
+		return -1
+	}, name)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// NormalizeEmail lowercases and trims an email address
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone keeps only the digits of a phone number
+func NormalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+func samePhone(a, b string) bool {
+	a, b = NormalizePhone(a), NormalizePhone(b)
+	if len(a) < minPhoneDigits || len(b) < minPhoneDigits {
+		return false
+	}
+	return strings.HasSuffix(a, b) || strings.HasSuffix(b, a)
+}
+
+// Similarity compares two normalized names from 0 (nothing alike) to 1 (equal). Word
+// order is ignored, so "smith jane" matches "jane smith".
+func Similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	return max(ratio(a, b), ratio(sortWords(a), sortWords(b)))
+}
+
+// ratio is 1 minus the Levenshtein distance relative to the longer string
+func ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func sortWords(s string) string {
+	words := strings.Fields(s)
+	slices.Sort(words)
+	return strings.Join(words, " ")
+}
+
+// MergeResult is what Merge changed
+type MergeResult struct {
+	Contact       *models.Contact `json:"contact"`       // the kept contact after the merge
+	FilledFields  []string        `json:"filledFields"`  // fields of the kept contact taken from a duplicate
+	RelinkedNotes []int           `json:"relinkedNotes"` // notes that linked a duplicate
+	Deleted       []int           `json:"deleted"`
+}
+
+// Merge merges duplicates into the contact keepID: fields the kept contact lacks are
+// taken from the first duplicate that has them, notes linking a duplicate link the
+// kept contact instead, and the duplicates are deleted
+func Merge(ctx context.Context, service api.CRMService, userID string, keepID int, duplicateIDs []int) (*MergeResult, error) {
+	if slices.Contains(duplicateIDs, keepID) {
+		return nil, fmt.Errorf("contact %d can't be merged into itself", keepID)
+	}
+
+	// A duplicate given twice would be deleted twice; the first mention decides the
+	// order fields are taken in
+	unique := make([]int, 0, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	duplicateIDs = unique
+
+	keep, err := service.GetContact(ctx, userID, keepID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact %d: %w", keepID, err)
+	}
+	duplicates := make([]*models.Contact, 0, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		duplicate, err := service.GetContact(ctx, userID, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get contact %d: %w", id, err)
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	result := &MergeResult{Contact: keep, FilledFields: []string{}, RelinkedNotes: []int{}, Deleted: []int{}}
+
+	fields := []struct {
+		name  string
+		field func(*models.Contact) **string
+	}{
+		{"company", func(c *models.Contact) **string { return &c.Company }},
+		{"phone", func(c *models.Contact) **string { return &c.PhoneNumber }},
+		{"email", func(c *models.Contact) **string { return &c.ContactEmail }},
+	}
+	merged := *keep
+	for _, f := range fields {
+		if value(*f.field(&merged)) != "" {
+			continue
+		}
+		for _, duplicate := range duplicates {
+			if v := *f.field(duplicate); value(v) != "" {
+				*f.field(&merged) = v
+				result.FilledFields = append(result.FilledFields, f.name)
+				break
+			}
+		}
+	}
+	if len(result.FilledFields) > 0 {
+		updated, err := service.UpdateContact(ctx, userID, keepID, merged.Name, merged.Company, merged.PhoneNumber, merged.ContactEmail)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update contact %d: %w", keepID, err)
+		}
+		result.Contact = updated
+	}
+
+	// Relink notes before deleting, so no note loses a contact
+	notes, err := service.ListNotesForUser(ctx, userID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list notes: %w", err)
+	}
+	for _, note := range notes {
+		relinked, changed := relink(note.ContactIDs, keepID, duplicateIDs)
+		if !changed {
+			continue
+		}
+		if _, err := service.UpdateNote(ctx, userID, note.ID, note.Title, value(note.Description), relinked); err != nil {
+			return result, fmt.Errorf("failed to relink note %d: %w", note.ID, err)
+		}
+		result.RelinkedNotes = append(result.RelinkedNotes, note.ID)
+	}
+
+	for _, id := range duplicateIDs {
+		if err := service.DeleteContact(ctx, userID, id); err != nil {
+			return result, fmt.Errorf("failed to delete contact %d: %w", id, err)
+		}
+		result.Deleted = append(result.Deleted, id)
+	}
+	return result, nil
+}
+
+// relink replaces the duplicate IDs in contactIDs with keepID, without listing it twice
+func relink(contactIDs []int, keepID int, duplicateIDs []int) ([]int, bool) {
+	changed := false
+	relinked := make([]int, 0, len(contactIDs))
+	for _, id := range contactIDs {
+		if slices.Contains(duplicateIDs, id) {
+			id = keepID
+			changed = true
+		}
+		if !slices.Contains(relinked, id) {
+			relinked = append(relinked, id)
+		}
+	}
+	return relinked, changed
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,147 @@
+package dedupe
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/fake"
+	"crm-admin/internal/models"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		atLeast float64
+		below   float64
+	}{
+		{a: "jane smith", b: "jane smith", atLeast: 1, below: 1.1},
+		{a: "jane smith", b: "smith jane", atLeast: 1, below: 1.1},
+		{a: "jane smith", b: "jane smyth", atLeast: 0.85, below: 1},
+		{a: "jane smith", b: "john doe", atLeast: 0, below: 0.5},
+		{a: "", b: "jane", atLeast: 0, below: 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got := Similarity(tt.a, tt.b)
+			if got < tt.atLeast || got >= tt.below {
+				t.Fatalf("Similarity(%q, %q) = %.2f, want [%.2f, %.2f)", tt.a, tt.b, got, tt.atLeast, tt.below)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := NormalizeName("  Smith,  Jane-Marie "); got != "smith jane marie" {
+		t.Fatalf("NormalizeName = %q", got)
+	}
+	if got := NormalizePhone("+1 (555) 010-0"); got != "15550100" {
+		t.Fatalf("NormalizePhone = %q", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	contacts := []models.Contact{
+		{ID: 1, Name: "Jane Smith", Company: ptr("Acme")},
+		{ID: 2, Name: "jane smyth", Company: ptr("ACME"), ContactEmail: ptr("jane@acme.test"), PhoneNumber: ptr("555 0100")},
+		{ID: 3, Name: "J. Smith", ContactEmail: ptr("JANE@acme.test")},
+		{ID: 4, Name: "Jane Smith", Company: ptr("Globex")},
+		{ID: 5, Name: "Bob", PhoneNumber: ptr("+1 (555) 222-3333")},
+		{ID: 6, Name: "Robert", PhoneNumber: ptr("555-222-3333")},
+		{ID: 7, Name: "Nobody Alike"},
+	}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		want      [][]int
+		wantKeep  []int
+	}{
+		{name: "default threshold", threshold: DefaultThreshold, want: [][]int{{1, 2, 3}, {5, 6}}, wantKeep: []int{2, 5}},
+		{name: "exact names only", threshold: 1, want: [][]int{{2, 3}, {5, 6}}, wantKeep: []int{2, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := Find(contacts, tt.threshold)
+			var got [][]int
+			var keep []int
+			for _, group := range groups {
+				var ids []int
+				for _, contact := range group.Contacts {
+					ids = append(ids, contact.ID)
+				}
+				got = append(got, ids)
+				keep = append(keep, group.KeepID)
+				if len(group.Reasons) == 0 {
+					t.Errorf("group %v has no reasons", ids)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(keep, tt.wantKeep) {
+				t.Fatalf("got groups %v keeping %v, want %v keeping %v", got, keep, tt.want, tt.wantKeep)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ctx := t.Context()
+	service := fake.New()
+	alice, _ := service.CreateUser(ctx, "alice", "secret")
+	keep, _ := service.CreateContact(ctx, "Jane Smith", alice.ID, ptr("Acme"), nil, nil)
+	dupA, _ := service.CreateContact(ctx, "Jane Smyth", alice.ID, ptr("Other"), nil, ptr("jane@acme.test"))
+	dupB, _ := service.CreateContact(ctx, "J. Smith", alice.ID, nil, ptr("555 0100"), ptr("j@acme.test"))
+	other, _ := service.CreateContact(ctx, "John", alice.ID, nil, nil, nil)
+	both, _ := service.CreateNote(ctx, "Both", "", []int{keep.ID, dupA.ID}, alice.ID)
+	dupOnly, _ := service.CreateNote(ctx, "Duplicate", "text", []int{dupB.ID, other.ID}, alice.ID)
+	untouched, _ := service.CreateNote(ctx, "Other", "", []int{other.ID}, alice.ID)
+
+	if _, err := Merge(ctx, service, alice.ID, keep.ID, []int{keep.ID}); err == nil || !strings.Contains(err.Error(), "itself") {
+		t.Fatalf("got %v, want an error merging a contact into itself", err)
+	}
+
+	// Repeated IDs are merged once
+	result, err := Merge(ctx, service, alice.ID, keep.ID, []int{dupA.ID, dupB.ID, dupA.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, _ := service.GetContact(ctx, alice.ID, keep.ID)
+	if merged.Name != "Jane Smith" || *merged.Company != "Acme" || *merged.PhoneNumber != "555 0100" || *merged.ContactEmail != "jane@acme.test" {
+		t.Fatalf("unexpected merged contact: %+v", merged)
+	}
+	if !reflect.DeepEqual(result.FilledFields, []string{"phone", "email"}) {
+		t.Fatalf("filled fields %v", result.FilledFields)
+	}
+	if !reflect.DeepEqual(result.Deleted, []int{dupA.ID, dupB.ID}) {
+		t.Fatalf("deleted %v", result.Deleted)
+	}
+	for _, id := range result.Deleted {
+		if _, err := service.GetContact(ctx, alice.ID, id); err == nil {
+			t.Fatalf("contact %d still exists", id)
+		}
+	}
+
+	wantLinks := map[int][]int{both.ID: {keep.ID}, dupOnly.ID: {keep.ID, other.ID}, untouched.ID: {other.ID}}
+	for id, want := range wantLinks {
+		note, _ := service.GetNote(ctx, alice.ID, id)
+		got := slices.Clone(note.ContactIDs)
+		slices.Sort(got)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("note %d links %v, want %v", id, got, want)
+		}
+	}
+	relinked := slices.Clone(result.RelinkedNotes)
+	slices.Sort(relinked)
+	if !slices.Equal(relinked, []int{both.ID, dupOnly.ID}) {
+		t.Fatalf("relinked notes %v", result.RelinkedNotes)
+	}
+	if note, _ := service.GetNote(ctx, alice.ID, dupOnly.ID); value(note.Description) != "text" {
+		t.Fatalf("description lost: %+v", note)
+	}
+}
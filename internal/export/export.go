@@ -0,0 +1,238 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/fsutil"
+	"crm-admin/internal/models"
+)
+
+// Format is the layout of an export
+type Format string
+
+const (
+	JSON   Format = "json"   // a single document holding the whole dataset
+	NDJSON Format = "ndjson" // one record per line, tagged with its type
+	CSV    Format = "csv"    // a directory of CSV files
+	CSVZip Format = "xlsx-free-csv-zip"
+)
+
+// Formats lists every supported format, in the order shown in help text
+var Formats = []Format{JSON, NDJSON, CSV, CSVZip}
+
+// SchemaVersion is written to JSON exports and changes whenever their layout does
+const SchemaVersion = 1
+
+// zipTime is the modification time of every file in a zip export, so that exporting
+// the same data twice produces identical archives
+var zipTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ParseFormat validates a --format value
+func ParseFormat(value string) (Format, error) {
+	format := Format(strings.ToLower(value))
+	if !slices.Contains(Formats, format) {
+		names := make([]string, len(Formats))
+		for i, format := range Formats {
+			names[i] = string(format)
+		}
+		return "", fmt.Errorf("unknown export format '%s' (use %s)", value, strings.Join(names, ", "))
+	}
+	return format, nil
+}
+
+// Dataset is everything the backend stores for one user
+type Dataset struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	User          models.User      `json:"user"`
+	Contacts      []models.Contact `json:"contacts"`
+	Notes         []models.Note    `json:"notes"`
+}
+
+// Fetch loads the user, contacts and notes of userID. Contacts, notes and the
+// contact IDs of each note are sorted by ID so exports of the same data are identical.
+func Fetch(ctx context.Context, service api.CRMService, userID string) (*Dataset, error) {
+	user, err := service.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return FetchFor(ctx, service, *user)
+}
+
+// FetchFor is Fetch for a user that has already been loaded. Contacts and notes are
+// fetched concurrently.
+func FetchFor(ctx context.Context, service api.CRMService, user models.User) (*Dataset, error) {
+	var (
+		wg       sync.WaitGroup
+		notes    []models.Note
+		notesErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		notes, notesErr = service.ListNotesForUser(ctx, user.ID)
+	}()
+
+	contacts, err := service.ListContacts(ctx, user.ID)
+	wg.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts of user %s: %w", user.ID, err)
+	}
+	if notesErr != nil {
+		return nil, fmt.Errorf("failed to list notes of user %s: %w", user.ID, notesErr)
+	}
+
+	user.Password = ""
+	dataset := &Dataset{SchemaVersion: SchemaVersion, User: user, Contacts: contacts, Notes: notes}
+	dataset.Sort()
+	return dataset, nil
+}
+
+// Sort orders contacts, notes and the contact IDs of each note by ID
+func (d *Dataset) Sort() {
+	if d.Contacts == nil {
+		d.Contacts = []models.Contact{}
+	}
+	if d.Notes == nil {
+		d.Notes = []models.Note{}
+	}
+	sort.Slice(d.Contacts, func(i, j int) bool { return d.Contacts[i].ID < d.Contacts[j].ID })
+	sort.Slice(d.Notes, func(i, j int) bool { return d.Notes[i].ID < d.Notes[j].ID })
+	for i := range d.Notes {
+		if d.Notes[i].ContactIDs == nil {
+			d.Notes[i].ContactIDs = []int{}
+		}
+		slices.Sort(d.Notes[i].ContactIDs)
+	}
+}
+
+// WriteJSON writes the dataset as one indented JSON document
+func (d *Dataset) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// ndjsonRecord is one line of an NDJSON export
+type ndjsonRecord struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// WriteNDJSON writes one line for the user, then one per contact and one per note
+func (d *Dataset) WriteNDJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	records := []ndjsonRecord{{Type: "user", Data: d.User}}
+	for _, contact := range d.Contacts {
+		records = append(records, ndjsonRecord{Type: "contact", Data: contact})
+	}
+	for _, note := range d.Notes {
+		records = append(records, ndjsonRecord{Type: "note", Data: note})
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write NDJSON: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes user.csv, contacts.csv, notes.csv and note_contacts.csv to dir.
+// note_contacts.csv is the join table linking notes to their contacts.
+func (d *Dataset) WriteCSV(dir string) error {
+	for _, table := range d.tables() {
+		data, err := table.encode()
+		if err != nil {
+			return err
+		}
+		if err := fsutil.WriteFileAtomic(filepath.Join(dir, table.name), data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteZip writes the CSV files of WriteCSV into a zip archive
+func (d *Dataset) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	for _, table := range d.tables() {
+		data, err := table.encode()
+		if err != nil {
+			return err
+		}
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: table.name, Method: zip.Deflate, Modified: zipTime})
+		if err != nil {
+			return fmt.Errorf("failed to write zip: %w", err)
+		}
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("failed to write zip: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write zip: %w", err)
+	}
+	return nil
+}
+
+// table is one CSV file of an export
+type table struct {
+	name string
+	rows [][]string // the first row is the header
+}
+
+func (t table) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(t.rows); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", t.name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *Dataset) tables() []table {
+	user := table{name: "user.csv", rows: [][]string{
+		{"id", "username"},
+		{d.User.ID, d.User.Username},
+	}}
+
+	contacts := table{name: "contacts.csv", rows: [][]string{{"id", "user_id", "name", "company", "phone_number", "contact_email"}}}
+	for _, c := range d.Contacts {
+		contacts.rows = append(contacts.rows, []string{
+			strconv.Itoa(c.ID), c.UserID, c.Name, value(c.Company), value(c.PhoneNumber), value(c.ContactEmail),
+		})
+	}
+
+	notes := table{name: "notes.csv", rows: [][]string{{"id", "user_id", "title", "description"}}}
+	links := table{name: "note_contacts.csv", rows: [][]string{{"note_id", "contact_id"}}}
+	for _, n := range d.Notes {
+		notes.rows = append(notes.rows, []string{strconv.Itoa(n.ID), n.UserID, n.Title, value(n.Description)})
+		for _, contactID := range n.ContactIDs {
+			links.rows = append(links.rows, []string{strconv.Itoa(n.ID), strconv.Itoa(contactID)})
+		}
+	}
+
+	return []table{user, contacts, notes, links}
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/models"
+)
+
+func testDataset() *Dataset {
+	company, description := "Acme, Inc.", "Met at \"the\" fair"
+	dataset := &Dataset{
+		SchemaVersion: SchemaVersion,
+		User:          models.User{ID: "u1", Username: "alice"},
+		Contacts: []models.Contact{
+			{ID: 7, UserID: "u1", Name: "John"},
+			{ID: 3, UserID: "u1", Name: "Jane", Company: &company},
+		},
+		Notes: []models.Note{
+			{ID: 9, UserID: "u1", Title: "Follow up", ContactIDs: []int{7, 3}, Description: &description},
+			{ID: 2, UserID: "u1", Title: "Intro"},
+		},
+	}
+	dataset.Sort()
+	return dataset
+}
+
+func TestWriteCSV(t *testing.T) {
+	dir := t.TempDir()
+	if err := testDataset().WriteCSV(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"user.csv":          "id,username\nu1,alice\n",
+		"contacts.csv":      "id,user_id,name,company,phone_number,contact_email\n3,u1,Jane,\"Acme, Inc.\",,\n7,u1,John,,,\n",
+		"notes.csv":         "id,user_id,title,description\n2,u1,Intro,\n9,u1,Follow up,\"Met at \"\"the\"\" fair\"\n",
+		"note_contacts.csv": "note_id,contact_id\n9,3\n9,7\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, data, content)
+		}
+	}
+}
+
+func TestWritersAreDeterministic(t *testing.T) {
+	writers := map[string]func(*Dataset, io.Writer) error{
+		"json":   (*Dataset).WriteJSON,
+		"ndjson": (*Dataset).WriteNDJSON,
+		"zip":    (*Dataset).WriteZip,
+	}
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			var first, second bytes.Buffer
+			if err := write(testDataset(), &first); err != nil {
+				t.Fatal(err)
+			}
+			if err := write(testDataset(), &second); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Fatal("two exports of the same data differ")
+			}
+		})
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testDataset().WriteNDJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var types []string
+	for _, line := range lines {
+		_, rest, _ := strings.Cut(line, `"type":"`)
+		kind, _, _ := strings.Cut(rest, `"`)
+		types = append(types, kind)
+	}
+	if got := strings.Join(types, ","); got != "user,contact,contact,note,note" {
+		t.Fatalf("record types = %s", got)
+	}
+	if !strings.Contains(lines[1], `"id":3`) || !strings.Contains(lines[3], `"contactIds":[]`) {
+		t.Fatalf("records are not sorted:\n%s", buf.String())
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testDataset().WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	if got := strings.Join(names, ","); got != "user.csv,contacts.csv,notes.csv,note_contacts.csv" {
+		t.Fatalf("files = %s", got)
+	}
+}
@@ -0,0 +1,573 @@
+package fake
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/models"
+)
+
+// Service is an in-memory implementation of api.CRMService. It follows the backend's
+// semantics: users get UUIDs, contacts and notes get increasing integer IDs, and
+// contacts and notes are only visible through the user that owns them.
+type Service struct {
+	// SelectedUserID plays the role of the selected user: methods given an empty
+	// userID operate on this user, like *api.Client does with the user context
+	SelectedUserID string
+
+	mu            sync.Mutex
+	users         []*storedUser
+	contacts      map[int]*models.Contact
+	notes         map[int]*models.Note
+	nextContactID int
+	nextNoteID    int
+}
+
+type storedUser struct {
+	user     models.User
+	password string
+}
+
+var _ api.CRMService = (*Service)(nil)
+
+// New creates an empty fake backend
+func New() *Service {
+	return &Service{
+		contacts:      make(map[int]*models.Contact),
+		notes:         make(map[int]*models.Note),
+		nextContactID: 1,
+		nextNoteID:    1,
+	}
+}
+
+// State is the complete data of a Service, for saving it to and loading it from a file.
+// Users carry their passwords.
+type State struct {
+	Users    []models.User    `json:"users"`
+	Contacts []models.Contact `json:"contacts"`
+	Notes    []models.Note    `json:"notes"`
+}
+
+// State returns a copy of all users, contacts and notes ordered by ID
+func (s *Service) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := State{
+		Users:    make([]models.User, 0, len(s.users)),
+		Contacts: make([]models.Contact, 0, len(s.contacts)),
+		Notes:    make([]models.Note, 0, len(s.notes)),
+	}
+	for _, stored := range s.users {
+		user := stored.user
+		user.Password = stored.password
+		state.Users = append(state.Users, user)
+	}
+	for _, id := range sortedKeys(s.contacts) {
+		state.Contacts = append(state.Contacts, *copyContact(s.contacts[id]))
+	}
+	for _, id := range sortedKeys(s.notes) {
+		state.Notes = append(state.Notes, *copyNote(s.notes[id]))
+	}
+	return state
+}
+
+// SetState replaces all data with state. New contacts and notes get IDs above the
+// highest ones in state.
+func (s *Service) SetState(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = nil
+	s.contacts = make(map[int]*models.Contact)
+	s.notes = make(map[int]*models.Note)
+	s.nextContactID, s.nextNoteID = 1, 1
+
+	for _, user := range state.Users {
+		password := user.Password
+		user.Password = ""
+		s.users = append(s.users, &storedUser{user: user, password: password})
+	}
+	for _, contact := range state.Contacts {
+		s.contacts[contact.ID] = copyContact(&contact)
+		s.nextContactID = max(s.nextContactID, contact.ID+1)
+	}
+	for _, note := range state.Notes {
+		s.notes[note.ID] = copyNote(&note)
+		s.nextNoteID = max(s.nextNoteID, note.ID+1)
+	}
+}
+
+// Password returns the stored password of a user, for checking password changes in tests
+func (s *Service) Password(userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored := s.findUser(userID); stored != nil {
+		return stored.password
+	}
+	return ""
+}
+
+// User operations
+
+func (s *Service) CreateUser(ctx context.Context, username, password string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.users {
+		if stored.user.Username == username {
+			return nil, api.NewError(http.StatusConflict, fmt.Sprintf("username '%s' is already taken", username))
+		}
+	}
+
+	stored := &storedUser{
+		user:     models.User{ID: newUUID(), Username: username},
+		password: password,
+	}
+	s.users = append(s.users, stored)
+
+	user := stored.user
+	return &user, nil
+}
+
+func (s *Service) ListUsers(ctx context.Context) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, stored := range s.users {
+		users = append(users, stored.user)
+	}
+	return users, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.findUser(userID)
+	if stored == nil {
+		return nil, userNotFound(userID)
+	}
+	user := stored.user
+	return &user, nil
+}
+
+func (s *Service) RenameUser(ctx context.Context, userID, username string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.findUser(userID)
+	if stored == nil {
+		return nil, userNotFound(userID)
+	}
+	for _, other := range s.users {
+		if other != stored && other.user.Username == username {
+			return nil, api.NewError(http.StatusConflict, fmt.Sprintf("username '%s' is already taken", username))
+		}
+	}
+
+	stored.user.Username = username
+	user := stored.user
+	return &user, nil
+}
+
+func (s *Service) SetUserPassword(ctx context.Context, userID, password string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.findUser(userID)
+	if stored == nil {
+		return userNotFound(userID)
+	}
+	stored.password = password
+	return nil
+}
+
+// DeleteUser removes the user together with their contacts and notes
+func (s *Service) DeleteUser(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.users, func(stored *storedUser) bool { return stored.user.ID == userID })
+	if index < 0 {
+		return userNotFound(userID)
+	}
+	s.users = slices.Delete(s.users, index, index+1)
+
+	for id, contact := range s.contacts {
+		if contact.UserID == userID {
+			delete(s.contacts, id)
+		}
+	}
+	for id, note := range s.notes {
+		if note.UserID == userID {
+			delete(s.notes, id)
+		}
+	}
+	return nil
+}
+
+// Contact operations
+
+func (s *Service) CreateContact(ctx context.Context, name, userID string, company, phoneNumber, contactEmail *string) (*models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, err := s.resolveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contact := &models.Contact{
+		ID:           s.nextContactID,
+		UserID:       owner,
+		Name:         name,
+		Company:      copyString(company),
+		PhoneNumber:  copyString(phoneNumber),
+		ContactEmail: copyString(contactEmail),
+	}
+	s.nextContactID++
+	s.contacts[contact.ID] = contact
+
+	return copyContact(contact), nil
+}
+
+func (s *Service) ListContacts(ctx context.Context, userID string) ([]models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, err := s.resolveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contacts := []models.Contact{}
+	for _, id := range sortedKeys(s.contacts) {
+		if contact := s.contacts[id]; contact.UserID == owner {
+			contacts = append(contacts, *copyContact(contact))
+		}
+	}
+	return contacts, nil
+}
+
+func (s *Service) GetContact(ctx context.Context, userID string, contactID int) (*models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact, err := s.ownedContact(userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+	return copyContact(contact), nil
+}
+
+func (s *Service) UpdateContact(ctx context.Context, userID string, contactID int, name string, company, phoneNumber, contactEmail *string) (*models.Contact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact, err := s.ownedContact(userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	contact.Name = name
+	contact.Company = copyString(company)
+	contact.PhoneNumber = copyString(phoneNumber)
+	contact.ContactEmail = copyString(contactEmail)
+	return copyContact(contact), nil
+}
+
+// DeleteContact removes the contact and unlinks it from the owner's notes
+func (s *Service) DeleteContact(ctx context.Context, userID string, contactID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact, err := s.ownedContact(userID, contactID)
+	if err != nil {
+		return err
+	}
+
+	delete(s.contacts, contactID)
+	for _, note := range s.notes {
+		if note.UserID == contact.UserID {
+			note.ContactIDs = slices.DeleteFunc(note.ContactIDs, func(id int) bool { return id == contactID })
+		}
+	}
+	return nil
+}
+
+// Note operations
+
+func (s *Service) CreateNote(ctx context.Context, title, description string, contactIDs []int, userID string) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, err := s.resolveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkContactIDs(owner, contactIDs); err != nil {
+		return nil, err
+	}
+
+	note := &models.Note{
+		ID:          s.nextNoteID,
+		UserID:      owner,
+		ContactIDs:  slices.Clone(contactIDs),
+		Title:       title,
+		Description: optionalString(description),
+	}
+	s.nextNoteID++
+	s.notes[note.ID] = note
+
+	return copyNote(note), nil
+}
+
+func (s *Service) ListNotesForUser(ctx context.Context, userID string) ([]models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, err := s.resolveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []models.Note{}
+	for _, id := range sortedKeys(s.notes) {
+		if note := s.notes[id]; note.UserID == owner {
+			notes = append(notes, *copyNote(note))
+		}
+	}
+	return notes, nil
+}
+
+func (s *Service) ListNotesForContact(ctx context.Context, userID string, contactID int) ([]models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact, err := s.ownedContact(userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []models.Note{}
+	for _, id := range sortedKeys(s.notes) {
+		note := s.notes[id]
+		if note.UserID == contact.UserID && slices.Contains(note.ContactIDs, contactID) {
+			notes = append(notes, *copyNote(note))
+		}
+	}
+	return notes, nil
+}
+
+func (s *Service) GetNote(ctx context.Context, userID string, noteID int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, err := s.ownedNote(userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	return copyNote(note), nil
+}
+
+func (s *Service) UpdateNote(ctx context.Context, userID string, noteID int, title, description string, contactIDs []int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, err := s.ownedNote(userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkContactIDs(note.UserID, contactIDs); err != nil {
+		return nil, err
+	}
+
+	note.Title = title
+	note.Description = optionalString(description)
+	note.ContactIDs = slices.Clone(contactIDs)
+	return copyNote(note), nil
+}
+
+func (s *Service) DeleteNote(ctx context.Context, userID string, noteID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.ownedNote(userID, noteID); err != nil {
+		return err
+	}
+	delete(s.notes, noteID)
+	return nil
+}
+
+// Helpers; callers hold s.mu
+
+func (s *Service) findUser(userID string) *storedUser {
+	for _, stored := range s.users {
+		if stored.user.ID == userID {
+			return stored
+		}
+	}
+	return nil
+}
+
+// resolveUser applies the selected-user fallback and checks that the user exists
+func (s *Service) resolveUser(userID string) (string, error) {
+	if userID == "" {
+		userID = s.SelectedUserID
+	}
+	if userID == "" {
+		return "", fmt.Errorf("user ID is required (use --user-id flag or select a user first)")
+	}
+	if s.findUser(userID) == nil {
+		return "", userNotFound(userID)
+	}
+	return userID, nil
+}
+
+// ownedContact returns a contact only when it belongs to the resolved user
+func (s *Service) ownedContact(userID string, contactID int) (*models.Contact, error) {
+	owner, err := s.resolveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	contact, ok := s.contacts[contactID]
+	if !ok || contact.UserID != owner {
+		return nil, api.NewError(http.StatusNotFound, fmt.Sprintf("contact %d not found", contactID))
+	}
+	return contact, nil
+}
+
+// ownedNote returns a note only when it belongs to the resolved user
+func (s *Service) ownedNote(userID string, noteID int) (*models.Note, error) {
+	owner, err := s.resolveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	note, ok := s.notes[noteID]
+	if !ok || note.UserID != owner {
+		return nil, api.NewError(http.StatusNotFound, fmt.Sprintf("note %d not found", noteID))
+	}
+	return note, nil
+}
+
+// checkContactIDs rejects links to contacts the user doesn't own
+func (s *Service) checkContactIDs(owner string, contactIDs []int) error {
+	for _, id := range contactIDs {
+		contact, ok := s.contacts[id]
+		if !ok || contact.UserID != owner {
+			return api.NewError(http.StatusBadRequest, fmt.Sprintf("contact %d does not belong to user %s", id, owner))
+		}
+	}
+	return nil
+}
+
+func userNotFound(userID string) error {
+	return api.NewError(http.StatusNotFound, fmt.Sprintf("user %s not found", userID))
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func copyContact(contact *models.Contact) *models.Contact {
+	copied := *contact
+	copied.Company = copyString(contact.Company)
+	copied.PhoneNumber = copyString(contact.PhoneNumber)
+	copied.ContactEmail = copyString(contact.ContactEmail)
+	return &copied
+}
+
+func copyNote(note *models.Note) *models.Note {
+	copied := *note
+	copied.ContactIDs = slices.Clone(note.ContactIDs)
+	copied.Description = copyString(note.Description)
+	return &copied
+}
+
+func copyString(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// newUUID returns a random version 4 UUID like the ones the backend assigns
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
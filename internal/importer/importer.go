@@ -0,0 +1,380 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"crm-admin/internal/models"
+)
+
+// Format is the syntax of an import file
+type Format string
+
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"   // an array of objects
+	NDJSON Format = "ndjson" // one object per line
+)
+
+// Fields of ContactRequest that a column can be mapped to
+const (
+	FieldName    = "name"
+	FieldCompany = "company"
+	FieldPhone   = "phoneNumber"
+	FieldEmail   = "contactEmail"
+)
+
+// fieldAliases are the column names recognised without a mapping, after normalizeColumn
+var fieldAliases = map[string]string{
+	"name":         FieldName,
+	"fullname":     FieldName,
+	"company":      FieldCompany,
+	"organization": FieldCompany,
+	"phone":        FieldPhone,
+	"phonenumber":  FieldPhone,
+	"email":        FieldEmail,
+	"contactemail": FieldEmail,
+}
+
+// Mapping maps column names, compared case-insensitively, to ContactRequest fields
+type Mapping map[string]string
+
+// Row is one record of an import file
+type Row struct {
+	Row     int // line number in CSV and NDJSON files, position in JSON arrays
+	Contact models.ContactRequest
+	Err     error // why the row is invalid, nil when it can be imported
+}
+
+// File is the parsed content of an import file
+type File struct {
+	Rows    []Row
+	Ignored []string // columns that don't map to any field
+}
+
+// DetectFormat returns the format matching the extension of path
+func DetectFormat(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSV, nil
+	case ".json":
+		return JSON, nil
+	case ".ndjson", ".jsonl":
+		return NDJSON, nil
+	default:
+		return "", fmt.Errorf("can't tell the format of '%s' from its extension (use --format csv, json or ndjson)", path)
+	}
+}
+
+// ParseFormat validates a --format value
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case CSV, JSON, NDJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown import format '%s' (use csv, json or ndjson)", value)
+	}
+}
+
+// ParseMapping parses "Column=field" pairs as given to --map
+func ParseMapping(pairs []string) (Mapping, error) {
+	mapping := make(Mapping)
+	for _, pair := range pairs {
+		column, field, ok := strings.Cut(pair, "=")
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping '%s' (expected \"Column=field\")", pair)
+		}
+		target, ok := fieldAliases[normalizeColumn(field)]
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping '%s': unknown field '%s' (use name, company, phone or email)", pair, field)
+		}
+		mapping[strings.ToLower(column)] = target
+	}
+	return mapping, nil
+}
+
+// Read parses an import file and validates every row. Columns not named in mapping
+// are matched to fields by their name, ignoring case, spaces, '-' and '_'.
+func Read(r io.Reader, format Format, mapping Mapping) (*File, error) {
+	switch format {
+	case CSV:
+		return readCSV(r, mapping)
+	case JSON:
+		return readJSON(r, mapping)
+	case NDJSON:
+		return readNDJSON(r, mapping)
+	default:
+		return nil, fmt.Errorf("unknown import format '%s'", format)
+	}
+}
+
+func readCSV(r io.Reader, mapping Mapping) (*File, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	// Rows with the wrong number of columns are reported as invalid rows below
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(header) > 0 {
+		// Spreadsheet programs like to start UTF-8 files with a byte order mark
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	fields, ignored, err := resolveColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{Ignored: ignored}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			file.Rows = append(file.Rows, Row{Row: line, Err: fmt.Errorf("has %d columns, but the header has %d", len(record), len(header))})
+			continue
+		}
+
+		values := make(map[string]string)
+		for i, value := range record {
+			if field := fields[i]; field != "" {
+				values[field] = value
+			}
+		}
+		file.Rows = append(file.Rows, newRow(line, values))
+	}
+	return file, nil
+}
+
+func readJSON(r io.Reader, mapping Mapping) (*File, error) {
+	var objects []map[string]any
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON (expected an array of objects): %w", err)
+	}
+
+	file := &File{}
+	resolver := newObjectResolver(mapping)
+	for i, object := range objects {
+		values, err := resolver.values(object)
+		file.Rows = append(file.Rows, objectRow(i+1, values, err))
+	}
+	file.Ignored = resolver.ignoredColumns()
+	return file, nil
+}
+
+func readNDJSON(r io.Reader, mapping Mapping) (*File, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	file := &File{}
+	resolver := newObjectResolver(mapping)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var object map[string]any
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&object); err != nil {
+			file.Rows = append(file.Rows, Row{Row: line, Err: fmt.Errorf("invalid JSON: %w", err)})
+			continue
+		}
+		values, err := resolver.values(object)
+		file.Rows = append(file.Rows, objectRow(line, values, err))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	file.Ignored = resolver.ignoredColumns()
+	return file, nil
+}
+
+// resolveColumns returns the field each CSV column maps to ("" when ignored)
+func resolveColumns(header []string, mapping Mapping) ([]string, []string, error) {
+	fields := make([]string, len(header))
+	var ignored []string
+	seen := make(map[string]bool)
+	for i, column := range header {
+		field := fieldFor(column, mapping)
+		if field == "" {
+			ignored = append(ignored, column)
+			continue
+		}
+		if seen[field] {
+			return nil, nil, fmt.Errorf("more than one column maps to the %s field", field)
+		}
+		seen[field] = true
+		fields[i] = field
+	}
+
+	for column := range mapping {
+		if !slices.ContainsFunc(header, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), column) }) {
+			return nil, nil, fmt.Errorf("mapped column '%s' is not in the CSV header", column)
+		}
+	}
+	if !seen[FieldName] {
+		return nil, nil, fmt.Errorf("no column maps to the name field (use --map \"Column=name\")")
+	}
+	return fields, ignored, nil
+}
+
+// objectResolver maps the keys of JSON objects to fields, remembering keys it ignored
+type objectResolver struct {
+	mapping Mapping
+	ignored map[string]bool
+}
+
+func newObjectResolver(mapping Mapping) *objectResolver {
+	return &objectResolver{mapping: mapping, ignored: make(map[string]bool)}
+}
+
+func (o *objectResolver) values(object map[string]any) (map[string]string, error) {
+	values := make(map[string]string)
+	for key, value := range object {
+		field := fieldFor(key, o.mapping)
+		if field == "" {
+			o.ignored[key] = true
+			continue
+		}
+		if _, ok := values[field]; ok {
+			return nil, fmt.Errorf("more than one key maps to the %s field", field)
+		}
+		switch value := value.(type) {
+		case nil:
+			values[field] = ""
+		case string:
+			values[field] = value
+		case json.Number:
+			values[field] = value.String()
+		case bool:
+			values[field] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("%s must be a string", key)
+		}
+	}
+	return values, nil
+}
+
+func (o *objectResolver) ignoredColumns() []string {
+	ignored := make([]string, 0, len(o.ignored))
+	for key := range o.ignored {
+		ignored = append(ignored, key)
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+func objectRow(row int, values map[string]string, err error) Row {
+	if err != nil {
+		return Row{Row: row, Err: err}
+	}
+	return newRow(row, values)
+}
+
+// fieldFor returns the field a column maps to, or "" if it doesn't map to any
+func fieldFor(column string, mapping Mapping) string {
+	if field, ok := mapping[strings.ToLower(strings.TrimSpace(column))]; ok {
+		return field
+	}
+	return fieldAliases[normalizeColumn(column)]
+}
+
+func normalizeColumn(column string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(column)))
+}
+
+// newRow builds a contact request from the values of a row and validates it
+func newRow(row int, values map[string]string) Row {
+	optional := func(field string) *string {
+		value := strings.TrimSpace(values[field])
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	contact := models.ContactRequest{
+		Name:         strings.TrimSpace(values[FieldName]),
+		Company:      optional(FieldCompany),
+		PhoneNumber:  optional(FieldPhone),
+		ContactEmail: optional(FieldEmail),
+	}
+	return Row{Row: row, Contact: contact, Err: Validate(contact)}
+}
+
+// Validate checks a contact request the way the backend would before creating it
+func Validate(contact models.ContactRequest) error {
+	if contact.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(contact.Name) > 255 {
+		return fmt.Errorf("name is longer than 255 characters")
+	}
+	if contact.ContactEmail != nil {
+		address, err := mail.ParseAddress(*contact.ContactEmail)
+		if err != nil || address.Address != *contact.ContactEmail {
+			return fmt.Errorf("invalid email '%s'", *contact.ContactEmail)
+		}
+	}
+	if contact.PhoneNumber != nil {
+		digits := 0
+		for _, r := range *contact.PhoneNumber {
+			switch {
+			case r >= '0' && r <= '9':
+				digits++
+			case strings.ContainsRune(" +-().", r):
+			default:
+				return fmt.Errorf("invalid phone number '%s'", *contact.PhoneNumber)
+			}
+		}
+		if digits < 3 {
+			return fmt.Errorf("invalid phone number '%s'", *contact.PhoneNumber)
+		}
+	}
+	return nil
+}
+
+// Key returns the keys that identify a contact as already existing: its email, and
+// its name together with its company. Both are compared case-insensitively.
+func Key(name string, company, email *string) []string {
+	var keys []string
+	if email != nil && *email != "" {
+		keys = append(keys, "email:"+strings.ToLower(*email))
+	}
+	companyName := ""
+	if company != nil {
+		companyName = *company
+	}
+	return append(keys, "name:"+strings.ToLower(strings.TrimSpace(name))+"\x00"+strings.ToLower(strings.TrimSpace(companyName)))
+}
@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      Format
+		input       string
+		mapping     []string
+		wantErr     string
+		wantNames   []string
+		wantInvalid map[int]string // row number to error
+		wantIgnored []string
+	}{
+		{
+			name:      "csv with default columns",
+			format:    CSV,
+			input:     "\ufeffName,Company,Phone Number,E-mail\nJane,Acme,+1 555 0100,jane@acme.test\nJohn,,,\n",
+			wantNames: []string{"Jane", "John"},
+		},
+		{
+			name:        "csv with mapping",
+			format:      CSV,
+			input:       "Full Name,Mail,Notes\nJane Doe,jane@acme.test,VIP\n",
+			mapping:     []string{"Full Name=name", "mail=email"},
+			wantNames:   []string{"Jane Doe"},
+			wantIgnored: []string{"Notes"},
+		},
+		{
+			name:        "csv validation",
+			format:      CSV,
+			input:       "name,email,phone\n,a@b.test,\nBad,not-an-email,\nWorse,,call me\n",
+			wantNames:   []string{"", "Bad", "Worse"},
+			wantInvalid: map[int]string{2: "name is required", 3: "invalid email", 4: "invalid phone number"},
+		},
+		{
+			name:        "csv with ragged rows",
+			format:      CSV,
+			input:       "name,company\nJane,Acme\nJohn,Acme,extra\nBob\nAlice,Globex\n",
+			wantNames:   []string{"Jane", "", "", "Alice"},
+			wantInvalid: map[int]string{3: "has 3 columns, but the header has 2", 4: "has 1 columns"},
+		},
+		{name: "csv without name column", format: CSV, input: "company\nAcme\n", wantErr: "no column maps to the name field"},
+		{name: "mapped column missing", format: CSV, input: "name\nJane\n", mapping: []string{"Full Name=name"}, wantErr: "not in the CSV header"},
+		{name: "two name columns", format: CSV, input: "name,full name\nJane,Jane\n", wantErr: "more than one column"},
+		{
+			name:      "json array",
+			format:    JSON,
+			input:     `[{"name":"Jane","phoneNumber":5550100},{"Name":"John","contactEmail":null}]`,
+			wantNames: []string{"Jane", "John"},
+		},
+		{name: "json object", format: JSON, input: `{"name":"Jane"}`, wantErr: "expected an array of objects"},
+		{
+			name:        "ndjson",
+			format:      NDJSON,
+			input:       "{\"name\":\"Jane\",\"source\":\"web\"}\n\n{broken\n{\"name\":\"John\",\"company\":{\"id\":1}}\n",
+			wantNames:   []string{"Jane", "", ""},
+			wantInvalid: map[int]string{3: "invalid JSON", 4: "company must be a string"},
+			wantIgnored: []string{"source"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := ParseMapping(tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := Read(strings.NewReader(tt.input), tt.format, mapping)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(file.Rows) != len(tt.wantNames) {
+				t.Fatalf("got %d rows, want %d: %+v", len(file.Rows), len(tt.wantNames), file.Rows)
+			}
+			for i, row := range file.Rows {
+				if row.Contact.Name != tt.wantNames[i] {
+					t.Errorf("row %d: name = %q, want %q", row.Row, row.Contact.Name, tt.wantNames[i])
+				}
+				want, invalid := tt.wantInvalid[row.Row]
+				switch {
+				case invalid && (row.Err == nil || !strings.Contains(row.Err.Error(), want)):
+					t.Errorf("row %d: error = %v, want %q", row.Row, row.Err, want)
+				case !invalid && row.Err != nil:
+					t.Errorf("row %d: unexpected error %v", row.Row, row.Err)
+				}
+			}
+			if strings.Join(file.Ignored, ",") != strings.Join(tt.wantIgnored, ",") {
+				t.Errorf("ignored = %v, want %v", file.Ignored, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestReadOptionalFields(t *testing.T) {
+	file, err := Read(strings.NewReader("name,company,phone,email\n Jane , Acme ,,\n"), CSV, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contact := file.Rows[0].Contact
+	if contact.Name != "Jane" || contact.Company == nil || *contact.Company != "Acme" {
+		t.Fatalf("values not trimmed: %+v", contact)
+	}
+	if contact.PhoneNumber != nil || contact.ContactEmail != nil {
+		t.Fatalf("empty values should be nil: %+v", contact)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	tests := []struct {
+		pair    string
+		want    string
+		wantErr string
+	}{
+		{pair: "Full Name=name", want: FieldName},
+		{pair: "Tel=phone", want: FieldPhone},
+		{pair: "Mail=contactEmail", want: FieldEmail},
+		{pair: "Notes=description", wantErr: "unknown field"},
+		{pair: "=name", wantErr: "expected"},
+		{pair: "name", wantErr: "expected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pair, func(t *testing.T) {
+			mapping, err := ParseMapping([]string{tt.pair})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			column, _, _ := strings.Cut(tt.pair, "=")
+			if got := mapping[strings.ToLower(column)]; got != tt.want {
+				t.Fatalf("field = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims are the registered and common claims of a JWT that the CLI shows
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Scopes    []string
+	IssuedAt  time.Time // zero when the token has no iat claim
+	ExpiresAt time.Time // zero when the token has no exp claim
+}
+
+// rawClaims covers the spellings different issuers use for scopes
+type rawClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Scope     json.RawMessage `json:"scope"`
+	Scopes    json.RawMessage `json:"scopes"`
+	Scp       json.RawMessage `json:"scp"`
+	IssuedAt  *json.Number    `json:"iat"`
+	ExpiresAt *json.Number    `json:"exp"`
+}
+
+// Parse decodes the claims of a JWT without verifying its signature. It is only
+// meant for showing information about a token; never trust the result.
+func Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %w", err)
+	}
+
+	var raw rawClaims
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+
+	claims := &Claims{
+		Subject:   raw.Subject,
+		Issuer:    raw.Issuer,
+		IssuedAt:  unixTime(raw.IssuedAt),
+		ExpiresAt: unixTime(raw.ExpiresAt),
+	}
+	for _, scopes := range []json.RawMessage{raw.Scope, raw.Scopes, raw.Scp} {
+		claims.Scopes = append(claims.Scopes, parseScopes(scopes)...)
+	}
+	return claims, nil
+}
+
+// Expired reports whether the token's expiry has passed at now
+func (c *Claims) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// ExpiresWithin reports whether the token expires within d of now, or already has
+func (c *Claims) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !c.ExpiresAt.IsZero() && now.Add(d).After(c.ExpiresAt)
+}
+
+// parseScopes accepts a space-separated string or a list of strings
+func parseScopes(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return strings.Fields(text)
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	return nil
+}
+
+func unixTime(value *json.Number) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+	seconds, err := value.Float64()
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0).UTC()
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+)
+
+func token(payload string) string {
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		wantErr     bool
+		wantSubject string
+		wantScopes  []string
+		wantExpires time.Time
+	}{
+		{name: "opaque token", token: "sk-0123456789", wantErr: true},
+		{name: "invalid payload", token: "a.b!.c", wantErr: true},
+		{name: "payload isn't JSON", token: token("not json"), wantErr: true},
+		{
+			name:        "scope string",
+			token:       token(`{"sub":"admin","scope":"users:read users:write","exp":1700000000}`),
+			wantSubject: "admin",
+			wantScopes:  []string{"users:read", "users:write"},
+			wantExpires: time.Unix(1700000000, 0).UTC(),
+		},
+		{
+			name:       "scopes list",
+			token:      token(`{"scopes":["admin"],"scp":["read"]}`),
+			wantScopes: []string{"admin", "read"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := Parse(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", claims.Subject, tt.wantSubject)
+			}
+			if !slices.Equal(claims.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", claims.Scopes, tt.wantScopes)
+			}
+			if !claims.ExpiresAt.Equal(tt.wantExpires) {
+				t.Errorf("expires = %v, want %v", claims.ExpiresAt, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		expiresAt   time.Time
+		wantExpired bool
+		wantSoon    bool
+	}{
+		{name: "no expiry"},
+		{name: "expired", expiresAt: now.Add(-time.Minute), wantExpired: true, wantSoon: true},
+		{name: "expires soon", expiresAt: now.Add(5 * time.Minute), wantSoon: true},
+		{name: "valid", expiresAt: now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{ExpiresAt: tt.expiresAt}
+			if got := claims.Expired(now); got != tt.wantExpired {
+				t.Errorf("Expired = %v, want %v", got, tt.wantExpired)
+			}
+			if got := claims.ExpiresWithin(now, 10*time.Minute); got != tt.wantSoon {
+				t.Errorf("ExpiresWithin = %v, want %v", got, tt.wantSoon)
+			}
+		})
+	}
+}
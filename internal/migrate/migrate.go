@@ -0,0 +1,314 @@
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/export"
+	"crm-admin/internal/fsutil"
+	"crm-admin/internal/models"
+)
+
+// State records which records of the target a migrated user, contact and note
+// became, so that running the same migration again updates them instead of
+// creating duplicates
+type State struct {
+	FromProfile  string      `json:"fromProfile"`
+	ToProfile    string      `json:"toProfile"`
+	SourceUserID string      `json:"sourceUserId"`
+	TargetUserID string      `json:"targetUserId,omitempty"`
+	Anonymized   bool        `json:"anonymized"`
+	Contacts     map[int]int `json:"contacts"` // source contact ID to target contact ID
+	Notes        map[int]int `json:"notes"`    // source note ID to target note ID
+	UpdatedAt    time.Time   `json:"updatedAt"`
+}
+
+// LoadState reads the state file at path, or starts a new state when it doesn't exist
+func LoadState(path, fromProfile, toProfile, sourceUserID string) (*State, error) {
+	state := &State{
+		FromProfile:  fromProfile,
+		ToProfile:    toProfile,
+		SourceUserID: sourceUserID,
+		Contacts:     make(map[int]int),
+		Notes:        make(map[int]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var stored State
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if stored.FromProfile != fromProfile || stored.ToProfile != toProfile || stored.SourceUserID != sourceUserID {
+		return nil, fmt.Errorf("state file %s belongs to the migration of user %s from '%s' to '%s'",
+			path, stored.SourceUserID, stored.FromProfile, stored.ToProfile)
+	}
+	if stored.Contacts == nil {
+		stored.Contacts = make(map[int]int)
+	}
+	if stored.Notes == nil {
+		stored.Notes = make(map[int]int)
+	}
+	return &stored, nil
+}
+
+// Save writes the state to path
+func (s *State) Save(path string) error {
+	s.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// Options control how a dataset is applied to the target
+type Options struct {
+	// Password is given to the user when it has to be created. When empty, a random
+	// password is generated and returned in the Result.
+	Password string
+
+	// Save is called whenever the state changed, so an interrupted migration can be resumed
+	Save func(*State) error
+}
+
+// Counts says what happened to the contacts or notes of a migration
+type Counts struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Result summarizes a migration
+type Result struct {
+	TargetUserID string `json:"targetUserId"`
+	Username     string `json:"username"`
+	UserCreated  bool   `json:"userCreated"`
+	Password     string `json:"password,omitempty"` // the generated password of a created user
+	Contacts     Counts `json:"contacts"`
+	Notes        Counts `json:"notes"`
+
+	// DroppedContacts maps source note IDs to the contacts they link that aren't part
+	// of the source data; those links are left out of the target notes
+	DroppedContacts map[int][]int `json:"droppedContacts,omitempty"`
+}
+
+// Apply makes the target hold the user, contacts and notes of source. Records the
+// state maps to target records are updated when they differ, everything else is
+// created; the contact IDs of notes are rewritten to the target's contact IDs.
+func Apply(ctx context.Context, target api.CRMService, source *export.Dataset, state *State, options Options) (*Result, error) {
+	save := func() error {
+		if options.Save == nil {
+			return nil
+		}
+		return options.Save(state)
+	}
+
+	result := &Result{Username: source.User.Username}
+
+	// A target user that has been deleted since the last run is created again, with
+	// all its contacts and notes
+	if state.TargetUserID != "" {
+		if _, err := target.GetUser(ctx, state.TargetUserID); api.IsNotFound(err) {
+			state.TargetUserID = ""
+		} else if err != nil {
+			return result, fmt.Errorf("failed to get target user: %w", err)
+		}
+	}
+	if state.TargetUserID == "" {
+		password := options.Password
+		if password == "" {
+			password = rand.Text()
+			result.Password = password
+		}
+		user, err := target.CreateUser(ctx, source.User.Username, password)
+		if err != nil {
+			return result, fmt.Errorf("failed to create user '%s': %w", source.User.Username, err)
+		}
+		state.TargetUserID = user.ID
+		state.Contacts = make(map[int]int)
+		state.Notes = make(map[int]int)
+		result.UserCreated = true
+		if err := save(); err != nil {
+			return result, err
+		}
+	}
+	result.TargetUserID = state.TargetUserID
+
+	existingContacts, err := target.ListContacts(ctx, state.TargetUserID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list target contacts: %w", err)
+	}
+	contactsByID := make(map[int]models.Contact)
+	for _, contact := range existingContacts {
+		contactsByID[contact.ID] = contact
+	}
+
+	inSource := make(map[int]bool)
+	for _, contact := range source.Contacts {
+		inSource[contact.ID] = true
+		current, ok := contactsByID[state.Contacts[contact.ID]]
+		switch {
+		case !ok:
+			created, err := target.CreateContact(ctx, contact.Name, state.TargetUserID, contact.Company, contact.PhoneNumber, contact.ContactEmail)
+			if err != nil {
+				return result, fmt.Errorf("failed to create contact %d: %w", contact.ID, err)
+			}
+			state.Contacts[contact.ID] = created.ID
+			result.Contacts.Created++
+			if err := save(); err != nil {
+				return result, err
+			}
+		case sameContact(current, contact):
+			result.Contacts.Unchanged++
+		default:
+			if _, err := target.UpdateContact(ctx, state.TargetUserID, current.ID, contact.Name, contact.Company, contact.PhoneNumber, contact.ContactEmail); err != nil {
+				return result, fmt.Errorf("failed to update contact %d: %w", current.ID, err)
+			}
+			result.Contacts.Updated++
+		}
+	}
+
+	existingNotes, err := target.ListNotesForUser(ctx, state.TargetUserID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list target notes: %w", err)
+	}
+	notesByID := make(map[int]models.Note)
+	for _, note := range existingNotes {
+		notesByID[note.ID] = note
+	}
+
+	for _, note := range source.Notes {
+		contactIDs := make([]int, 0, len(note.ContactIDs))
+		for _, id := range note.ContactIDs {
+			targetID, ok := state.Contacts[id]
+			if !ok || !inSource[id] {
+				if result.DroppedContacts == nil {
+					result.DroppedContacts = make(map[int][]int)
+				}
+				result.DroppedContacts[note.ID] = append(result.DroppedContacts[note.ID], id)
+				continue
+			}
+			contactIDs = append(contactIDs, targetID)
+		}
+		slices.Sort(contactIDs)
+		description := ""
+		if note.Description != nil {
+			description = *note.Description
+		}
+
+		current, ok := notesByID[state.Notes[note.ID]]
+		switch {
+		case !ok:
+			created, err := target.CreateNote(ctx, note.Title, description, contactIDs, state.TargetUserID)
+			if err != nil {
+				return result, fmt.Errorf("failed to create note %d: %w", note.ID, err)
+			}
+			state.Notes[note.ID] = created.ID
+			result.Notes.Created++
+			if err := save(); err != nil {
+				return result, err
+			}
+		case sameNote(current, note.Title, description, contactIDs):
+			result.Notes.Unchanged++
+		default:
+			if _, err := target.UpdateNote(ctx, state.TargetUserID, current.ID, note.Title, description, contactIDs); err != nil {
+				return result, fmt.Errorf("failed to update note %d: %w", current.ID, err)
+			}
+			result.Notes.Updated++
+		}
+	}
+
+	return result, save()
+}
+
+func sameContact(a, b models.Contact) bool {
+	return a.Name == b.Name && equal(a.Company, b.Company) && equal(a.PhoneNumber, b.PhoneNumber) && equal(a.ContactEmail, b.ContactEmail)
+}
+
+func sameNote(note models.Note, title, description string, contactIDs []int) bool {
+	current := slices.Clone(note.ContactIDs)
+	slices.Sort(current)
+	return note.Title == title && value(note.Description) == description && slices.Equal(current, contactIDs)
+}
+
+func equal(a, b *string) bool {
+	return value(a) == value(b)
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Anonymize returns a copy of dataset with every name, company, phone number, email,
+// title and description replaced. The replacements are derived from the record IDs,
+// so anonymizing the same data twice gives the same result and re-runs don't flap.
+func Anonymize(dataset *export.Dataset) *export.Dataset {
+	anonymized := &export.Dataset{
+		SchemaVersion: dataset.SchemaVersion,
+		User:          models.User{ID: dataset.User.ID, Username: "user-" + pseudonym("user", dataset.User.ID)},
+		Contacts:      make([]models.Contact, len(dataset.Contacts)),
+		Notes:         make([]models.Note, len(dataset.Notes)),
+	}
+
+	for i, contact := range dataset.Contacts {
+		id := fmt.Sprint(contact.ID)
+		anonymized.Contacts[i] = models.Contact{
+			ID:     contact.ID,
+			UserID: contact.UserID,
+			Name:   "Contact " + pseudonym("contact", id),
+		}
+		if contact.Company != nil {
+			anonymized.Contacts[i].Company = ptr("Company " + pseudonym("company", id))
+		}
+		if contact.PhoneNumber != nil {
+			// 555-01xx numbers are reserved for fiction
+			anonymized.Contacts[i].PhoneNumber = ptr(fmt.Sprintf("+1 555 01%02d", contact.ID%100))
+		}
+		if contact.ContactEmail != nil {
+			anonymized.Contacts[i].ContactEmail = ptr("contact-" + pseudonym("email", id) + "@example.invalid")
+		}
+	}
+
+	for i, note := range dataset.Notes {
+		anonymized.Notes[i] = models.Note{
+			ID:         note.ID,
+			UserID:     note.UserID,
+			ContactIDs: slices.Clone(note.ContactIDs),
+			Title:      fmt.Sprintf("Note %d", note.ID),
+		}
+		if note.Description != nil && *note.Description != "" {
+			anonymized.Notes[i].Description = ptr("Anonymized description of note " + pseudonym("note", fmt.Sprint(note.ID)))
+		}
+	}
+	return anonymized
+}
+
+func pseudonym(kind, id string) string {
+	sum := sha256.Sum256([]byte(kind + ":" + id))
+	return hex.EncodeToString(sum[:4])
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,152 @@
+package migrate
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/export"
+	"crm-admin/internal/fake"
+	"crm-admin/internal/models"
+)
+
+func sourceDataset(t *testing.T) (*fake.Service, *export.Dataset) {
+	t.Helper()
+	ctx := t.Context()
+	source := fake.New()
+	user, _ := source.CreateUser(ctx, "alice", "secret")
+	company, email := "Acme", "jane@acme.test"
+	jane, _ := source.CreateContact(ctx, "Jane", user.ID, &company, nil, &email)
+	john, _ := source.CreateContact(ctx, "John", user.ID, nil, nil, nil)
+	source.CreateNote(ctx, "Call", "About the deal", []int{john.ID, jane.ID}, user.ID)
+
+	dataset, err := export.Fetch(ctx, source, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source, dataset
+}
+
+func TestApplyIsIdempotent(t *testing.T) {
+	ctx := t.Context()
+	source, dataset := sourceDataset(t)
+	target := fake.New()
+	// Make the target assign other contact IDs than the source
+	other, _ := target.CreateUser(ctx, "other", "secret")
+	target.CreateContact(ctx, "Filler", other.ID, nil, nil, nil)
+	target.CreateContact(ctx, "Filler", other.ID, nil, nil, nil)
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	apply := func() *Result {
+		t.Helper()
+		state, err := LoadState(path, "prod", "staging", dataset.User.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := Apply(ctx, target, dataset, state, Options{Password: "pw", Save: func(s *State) error { return s.Save(path) }})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := apply()
+	if !first.UserCreated || first.Contacts.Created != 2 || first.Notes.Created != 1 {
+		t.Fatalf("first run: %+v", first)
+	}
+	notes, _ := target.ListNotesForUser(ctx, first.TargetUserID)
+	contacts, _ := target.ListContacts(ctx, first.TargetUserID)
+	var copied []int
+	for _, contact := range contacts {
+		copied = append(copied, contact.ID)
+	}
+	if len(notes) != 1 || !reflect.DeepEqual(notes[0].ContactIDs, copied) {
+		t.Fatalf("note not linked to the copied contacts %v: %+v", copied, notes)
+	}
+
+	second := apply()
+	if second.UserCreated || second.Contacts != (Counts{Unchanged: 2}) || second.Notes != (Counts{Unchanged: 1}) {
+		t.Fatalf("second run changed something: %+v", second)
+	}
+
+	// Changes on the source are synced on the next run
+	jane := dataset.Contacts[0]
+	source.UpdateContact(ctx, dataset.User.ID, jane.ID, "Jane Doe", jane.Company, nil, jane.ContactEmail)
+	dataset, _ = export.Fetch(ctx, source, dataset.User.ID)
+	third := apply()
+	if third.Contacts != (Counts{Updated: 1, Unchanged: 1}) {
+		t.Fatalf("third run: %+v", third)
+	}
+	if contacts, _ := target.ListContacts(ctx, first.TargetUserID); len(contacts) != 2 {
+		t.Fatalf("contacts were duplicated: %+v", contacts)
+	}
+
+	// A deleted target user is created again
+	target.DeleteUser(ctx, first.TargetUserID)
+	if fourth := apply(); !fourth.UserCreated || fourth.Contacts.Created != 2 {
+		t.Fatalf("fourth run: %+v", fourth)
+	}
+}
+
+func TestApplyDropsDanglingContacts(t *testing.T) {
+	ctx := t.Context()
+	dataset := &export.Dataset{
+		User:     models.User{ID: "u1", Username: "alice"},
+		Contacts: []models.Contact{{ID: 5, UserID: "u1", Name: "Jane"}},
+		Notes: []models.Note{
+			{ID: 7, UserID: "u1", Title: "Call", ContactIDs: []int{5, 99}},
+			{ID: 8, UserID: "u1", Title: "Lunch"},
+		},
+	}
+	target := fake.New()
+	state, _ := LoadState(filepath.Join(t.TempDir(), "state.json"), "prod", "staging", "u1")
+
+	result, err := Apply(ctx, target, dataset, state, Options{Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Notes.Created != 2 || !reflect.DeepEqual(result.DroppedContacts, map[int][]int{7: {99}}) {
+		t.Fatalf("got %+v, want both notes created and contact 99 of note 7 dropped", result)
+	}
+	notes, _ := target.ListNotesForUser(ctx, result.TargetUserID)
+	for _, note := range notes {
+		if note.ID == state.Notes[7] && !reflect.DeepEqual(note.ContactIDs, []int{state.Contacts[5]}) {
+			t.Fatalf("note links %v, want only the copied contact %d", note.ContactIDs, state.Contacts[5])
+		}
+	}
+}
+
+func TestLoadStateRejectsOtherMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, _ := LoadState(path, "prod", "staging", "u1")
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(path, "prod", "dev", "u1"); err == nil || !strings.Contains(err.Error(), "belongs to") {
+		t.Fatalf("got error %v, want a mismatch", err)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	_, dataset := sourceDataset(t)
+
+	anonymized := Anonymize(dataset)
+	if !reflect.DeepEqual(anonymized, Anonymize(dataset)) {
+		t.Fatal("anonymizing twice gives different results")
+	}
+
+	text := strings.Join([]string{anonymized.User.Username, anonymized.Contacts[0].Name, *anonymized.Contacts[0].Company,
+		*anonymized.Contacts[0].ContactEmail, anonymized.Notes[0].Title, *anonymized.Notes[0].Description}, " ")
+	for _, secret := range []string{"alice", "Jane", "Acme", "jane@", "Call", "deal"} {
+		if strings.Contains(text, secret) {
+			t.Fatalf("anonymized data contains %q: %s", secret, text)
+		}
+	}
+	if anonymized.Contacts[1].Company != nil {
+		t.Fatal("anonymizing added a company")
+	}
+	if !reflect.DeepEqual(anonymized.Notes[0].ContactIDs, dataset.Notes[0].ContactIDs) {
+		t.Fatal("anonymizing changed the note links")
+	}
+}
@@ -0,0 +1,390 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/fake"
+	"crm-admin/internal/fsutil"
+	"crm-admin/internal/models"
+)
+
+// Options configure a mock backend
+type Options struct {
+	// Prefix is the path the API is served under, matching the path of the base URL
+	// the CLI is configured with (the default base URL ends in /api)
+	Prefix string
+
+	// Token, when set, is the bearer token every request must carry
+	Token string
+
+	// DataFile, when set, is the JSON file the data is loaded from and saved to after
+	// every change. Without it the data only lives in memory.
+	DataFile string
+
+	// Log receives one line per request; nil disables request logging
+	Log io.Writer
+}
+
+// Server is an HTTP server that behaves like the CRM backend, including its quirks:
+// a new user's ID is only returned in the "id" response header
+type Server struct {
+	store   *fake.Service
+	options Options
+	saveMu  sync.Mutex
+}
+
+// New creates a mock backend serving the data in store. If a data file is configured
+// and exists, its contents replace the store's data.
+func New(store *fake.Service, options Options) (*Server, error) {
+	options.Prefix = strings.TrimSuffix(options.Prefix, "/")
+	server := &Server{store: store, options: options}
+
+	if options.DataFile != "" {
+		if err := server.load(); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	p := s.options.Prefix
+
+	mux.HandleFunc("POST "+p+"/api/user", s.createUser)
+	mux.HandleFunc("GET "+p+"/api/user", s.listUsers)
+	mux.HandleFunc("GET "+p+"/api/user/{userID}", s.getUser)
+	mux.HandleFunc("PUT "+p+"/api/user/{userID}", s.renameUser)
+	mux.HandleFunc("DELETE "+p+"/api/user/{userID}", s.deleteUser)
+	mux.HandleFunc("PUT "+p+"/api/user/{userID}/password", s.setPassword)
+
+	mux.HandleFunc("POST "+p+"/api/users/{userID}/contacts", s.createContact)
+	mux.HandleFunc("GET "+p+"/api/users/{userID}/contacts", s.listContacts)
+	mux.HandleFunc("POST "+p+"/api/users/{userID}/contacts/notes", s.createNote)
+	mux.HandleFunc("GET "+p+"/api/users/{userID}/contacts/notes", s.listNotes)
+
+	// /contacts/{id}/notes and /contacts/notes/{id} overlap, so the
+	// remaining contact and note routes are dispatched by hand
+	mux.HandleFunc(p+"/api/users/{userID}/contacts/{rest...}", s.contactOrNote)
+
+	return s.middleware(mux)
+}
+
+// middleware checks the bearer token and logs requests
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		if s.options.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.options.Token {
+			writeError(recorder, api.NewError(http.StatusUnauthorized, "missing or invalid bearer token"))
+		} else {
+			next.ServeHTTP(recorder, r)
+		}
+
+		if s.options.Log != nil {
+			fmt.Fprintf(s.options.Log, "%s %s %s %d %s\n", start.Format(time.TimeOnly), r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Microsecond))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// User handlers
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UserRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		writeError(w, api.NewError(http.StatusBadRequest, "username and password are required"))
+		return
+	}
+
+	user, err := s.store.CreateUser(r.Context(), req.Username, req.Password)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.save()
+
+	// Like the real backend: the ID is only in the header, the body is empty
+	w.Header().Set("id", user.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.store.ListUsers(r.Context())
+	respond(w, http.StatusOK, users, err)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	user, err := s.store.GetUser(r.Context(), r.PathValue("userID"))
+	respond(w, http.StatusOK, user, err)
+}
+
+func (s *Server) renameUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UserUpdateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Username == "" {
+		writeError(w, api.NewError(http.StatusBadRequest, "username is required"))
+		return
+	}
+
+	user, err := s.store.RenameUser(r.Context(), r.PathValue("userID"), req.Username)
+	s.saveIf(err)
+	respond(w, http.StatusOK, user, err)
+}
+
+func (s *Server) setPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.UserUpdateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Password == "" {
+		writeError(w, api.NewError(http.StatusBadRequest, "password is required"))
+		return
+	}
+
+	err := s.store.SetUserPassword(r.Context(), r.PathValue("userID"), req.Password)
+	s.saveIf(err)
+	respond(w, http.StatusNoContent, nil, err)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	err := s.store.DeleteUser(r.Context(), r.PathValue("userID"))
+	s.saveIf(err)
+	respond(w, http.StatusNoContent, nil, err)
+}
+
+// Contact and note handlers
+
+func (s *Server) createContact(w http.ResponseWriter, r *http.Request) {
+	var req models.ContactRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, api.NewError(http.StatusBadRequest, "name is required"))
+		return
+	}
+
+	contact, err := s.store.CreateContact(r.Context(), req.Name, r.PathValue("userID"), req.Company, req.PhoneNumber, req.ContactEmail)
+	s.saveIf(err)
+	respond(w, http.StatusCreated, contact, err)
+}
+
+func (s *Server) listContacts(w http.ResponseWriter, r *http.Request) {
+	contacts, err := s.store.ListContacts(r.Context(), r.PathValue("userID"))
+	respond(w, http.StatusOK, contacts, err)
+}
+
+func (s *Server) createNote(w http.ResponseWriter, r *http.Request) {
+	var req models.NoteRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Title == "" {
+		writeError(w, api.NewError(http.StatusBadRequest, "title is required"))
+		return
+	}
+
+	note, err := s.store.CreateNote(r.Context(), req.Title, req.Description, req.ContactIDs, r.PathValue("userID"))
+	s.saveIf(err)
+	respond(w, http.StatusCreated, note, err)
+}
+
+func (s *Server) listNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := s.store.ListNotesForUser(r.Context(), r.PathValue("userID"))
+	respond(w, http.StatusOK, notes, err)
+}
+
+// contactOrNote serves /contacts/{id}, /contacts/{id}/notes and /contacts/notes/{id}
+func (s *Server) contactOrNote(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userID")
+	parts := strings.Split(r.PathValue("rest"), "/")
+
+	switch {
+	case len(parts) == 1:
+		id, ok := pathID(w, parts[0])
+		if ok {
+			s.contact(w, r, userID, id)
+		}
+	case len(parts) == 2 && parts[0] == "notes":
+		id, ok := pathID(w, parts[1])
+		if ok {
+			s.note(w, r, userID, id)
+		}
+	case len(parts) == 2 && parts[1] == "notes":
+		id, ok := pathID(w, parts[0])
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeError(w, api.NewError(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		notes, err := s.store.ListNotesForContact(r.Context(), userID, id)
+		respond(w, http.StatusOK, notes, err)
+	default:
+		writeError(w, api.NewError(http.StatusNotFound, "no such endpoint"))
+	}
+}
+
+func (s *Server) contact(w http.ResponseWriter, r *http.Request, userID string, contactID int) {
+	switch r.Method {
+	case http.MethodGet:
+		contact, err := s.store.GetContact(r.Context(), userID, contactID)
+		respond(w, http.StatusOK, contact, err)
+	case http.MethodPut:
+		var req models.ContactRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		contact, err := s.store.UpdateContact(r.Context(), userID, contactID, req.Name, req.Company, req.PhoneNumber, req.ContactEmail)
+		s.saveIf(err)
+		respond(w, http.StatusOK, contact, err)
+	case http.MethodDelete:
+		err := s.store.DeleteContact(r.Context(), userID, contactID)
+		s.saveIf(err)
+		respond(w, http.StatusNoContent, nil, err)
+	default:
+		writeError(w, api.NewError(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+func (s *Server) note(w http.ResponseWriter, r *http.Request, userID string, noteID int) {
+	switch r.Method {
+	case http.MethodGet:
+		note, err := s.store.GetNote(r.Context(), userID, noteID)
+		respond(w, http.StatusOK, note, err)
+	case http.MethodPut:
+		var req models.NoteRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		note, err := s.store.UpdateNote(r.Context(), userID, noteID, req.Title, req.Description, req.ContactIDs)
+		s.saveIf(err)
+		respond(w, http.StatusOK, note, err)
+	case http.MethodDelete:
+		err := s.store.DeleteNote(r.Context(), userID, noteID)
+		s.saveIf(err)
+		respond(w, http.StatusNoContent, nil, err)
+	default:
+		writeError(w, api.NewError(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// Persistence
+
+func (s *Server) load() error {
+	data, err := os.ReadFile(s.options.DataFile)
+	if os.IsNotExist(err) {
+		return nil // Start empty; the file is created on the first change
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read data file: %w", err)
+	}
+
+	var state fake.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse data file %s: %w", s.options.DataFile, err)
+	}
+	s.store.SetState(state)
+	return nil
+}
+
+// save writes the data file after a change; failures are logged, not returned to the client
+func (s *Server) save() {
+	if s.options.DataFile == "" {
+		return
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	data, err := json.MarshalIndent(s.store.State(), "", "  ")
+	if err == nil {
+		err = fsutil.WriteFileAtomic(s.options.DataFile, data, 0600)
+	}
+	if err != nil && s.options.Log != nil {
+		fmt.Fprintf(s.options.Log, "failed to save data file: %v\n", err)
+	}
+}
+
+// saveIf saves the data when a change succeeded
+func (s *Server) saveIf(err error) {
+	if err == nil {
+		s.save()
+	}
+}
+
+// Helpers
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, api.NewError(http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err)))
+		return false
+	}
+	return true
+}
+
+func pathID(w http.ResponseWriter, value string) (int, bool) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		writeError(w, api.NewError(http.StatusBadRequest, fmt.Sprintf("invalid ID '%s'", value)))
+		return 0, false
+	}
+	return id, true
+}
+
+// respond writes v as JSON with status, or the error if err is set
+func respond(w http.ResponseWriter, status int, v any, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, v)
+}
+
+// writeError answers with the error's status and a {"message": ...} body.
+// Errors that don't carry a status are the client's fault.
+func writeError(w http.ResponseWriter, err error) {
+	status, message := http.StatusBadRequest, err.Error()
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		status, message = apiErr.StatusCode, apiErr.Message
+	}
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
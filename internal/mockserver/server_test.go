@@ -0,0 +1,153 @@
+package mockserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/config"
+	"crm-admin/internal/fake"
+)
+
+// newTestClient starts a mock backend and returns an API client configured for it
+func newTestClient(t *testing.T, options Options) (*api.Client, *httptest.Server) {
+	t.Helper()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	token := options.Token
+	if token == "" {
+		token = "unchecked"
+	}
+	t.Setenv("CRM_ADMIN_API_KEY", token)
+	t.Chdir(t.TempDir())
+
+	server, err := New(fake.New(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+
+	config.SetBaseURL(httpServer.URL + options.Prefix)
+	config.SetRetries(0)
+	t.Cleanup(func() {
+		config.SetBaseURL("")
+		config.SetRetries(-1)
+	})
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	return api.New(), httpServer
+}
+
+func TestClientAgainstMockServer(t *testing.T) {
+	client, _ := newTestClient(t, Options{Prefix: "/api", Token: "secret"})
+	ctx := t.Context()
+
+	user, err := client.CreateUser(ctx, "alice", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateUser(ctx, "alice", "pw"); !api.IsConflict(err) {
+		t.Fatalf("duplicate user: got %v, want conflict", err)
+	}
+
+	jane, err := client.CreateContact(ctx, "Jane", user.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	john, err := client.CreateContact(ctx, "John", user.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	note, err := client.CreateNote(ctx, "Kickoff", "", []int{jane.ID, john.ID}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := client.ListNotesForContact(ctx, user.ID, john.ID)
+	if err != nil || len(notes) != 1 || notes[0].ID != note.ID {
+		t.Fatalf("notes for contact: %+v, %v", notes, err)
+	}
+
+	if err := client.DeleteContact(ctx, user.ID, jane.ID); err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := client.GetNote(ctx, user.ID, note.ID)
+	if err != nil || !slices.Equal(fetched.ContactIDs, []int{john.ID}) {
+		t.Fatalf("note after contact delete: %+v, %v", fetched, err)
+	}
+
+	if err := client.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListContacts(ctx, user.ID); !api.IsNotFound(err) {
+		t.Fatalf("contacts of deleted user: got %v, want not found", err)
+	}
+}
+
+func TestMockServerRequiresToken(t *testing.T) {
+	_, httpServer := newTestClient(t, Options{Token: "secret"})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "valid token", header: "Bearer secret", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, httpServer.URL+"/api/user", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockServerPersistsData(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "data.json")
+	client, _ := newTestClient(t, Options{DataFile: dataFile})
+
+	user, err := client.CreateUser(t.Context(), "alice", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateContact(t.Context(), "Jane", user.ID, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new server on the same file sees the data and continues the ID sequence
+	store := fake.New()
+	if _, err := New(store, Options{DataFile: dataFile}); err != nil {
+		t.Fatal(err)
+	}
+	contact, err := store.CreateContact(t.Context(), "John", user.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contact.ID != 2 {
+		t.Fatalf("contact ID = %d, want 2", contact.ID)
+	}
+
+	users, _ := store.ListUsers(t.Context())
+	if len(users) != 1 || users[0].Username != "alice" {
+		t.Fatalf("users = %+v", users)
+	}
+}
@@ -32,6 +32,31 @@ type UserRequest struct {
 	Password string `json:"password"`
 }
 
+// UserUpdateRequest changes a user's username or password; empty fields are left unchanged
+type UserUpdateRequest struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// LoginRequest exchanges a username and password for a token
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// TokenResponse is the answer of the login and refresh endpoints. Both common spellings of the
+// token field are accepted.
+type TokenResponse struct {
+	Token        string `json:"token,omitempty"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// RefreshRequest exchanges a refresh token for a new token
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 type ContactRequest struct {
 	Name         string  `json:"name"`
 	Company      *string `json:"company,omitempty"`
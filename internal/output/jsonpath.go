@@ -0,0 +1,331 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of kubectl's JSONPath syntax that is useful for CRM data:
+//
+//	{.field}            field of the current object
+//	{.[0]} {.[*]}       index into, or iterate over, an array
+//	{.*}                every value of an object or array
+//	{range .[*]}...{end} repeat the enclosed template for every result
+//	{"\n"}              quoted string literal
+//
+// A leading '$' is accepted and ignored. Keys that do not exist produce no output.
+
+type jsonPathNode struct {
+	text     string         // literal text, when path is nil and children is nil
+	path     []pathSegment  // expression to evaluate
+	isRange  bool           // range block over the results of path
+	children []jsonPathNode // body of a range block
+}
+
+type pathSegment struct {
+	field    string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+// jsonPathTemplate is a parsed JSONPath template
+type jsonPathTemplate struct {
+	nodes []jsonPathNode
+}
+
+func parseJSONPath(template string) (*jsonPathTemplate, error) {
+	tokens, err := tokenizeJSONPath(template)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, _, err := buildJSONPathNodes(tokens, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonPathTemplate{nodes: nodes}, nil
+}
+
+// jsonPathToken is either literal text or the contents of a {...} expression
+type jsonPathToken struct {
+	value  string
+	isExpr bool
+}
+
+func tokenizeJSONPath(template string) ([]jsonPathToken, error) {
+	var tokens []jsonPathToken
+	var text strings.Builder
+
+	for i := 0; i < len(template); i++ {
+		if template[i] != '{' {
+			text.WriteByte(template[i])
+			continue
+		}
+
+		end, err := findExprEnd(template, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if text.Len() > 0 {
+			tokens = append(tokens, jsonPathToken{value: text.String()})
+			text.Reset()
+		}
+		tokens = append(tokens, jsonPathToken{value: strings.TrimSpace(template[i+1 : end]), isExpr: true})
+		i = end
+	}
+
+	if text.Len() > 0 {
+		tokens = append(tokens, jsonPathToken{value: text.String()})
+	}
+	return tokens, nil
+}
+
+// findExprEnd returns the index of the '}' closing an expression, skipping quoted strings
+func findExprEnd(template string, start int) (int, error) {
+	inQuote := false
+	for i := start; i < len(template); i++ {
+		switch {
+		case inQuote && template[i] == '\\':
+			i++
+		case template[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && template[i] == '}':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid jsonpath template: unclosed '{' at position %d", start)
+}
+
+// buildJSONPathNodes turns tokens into nodes. Inside a range it stops at the closing {end}
+// and returns the tokens that follow it.
+func buildJSONPathNodes(tokens []jsonPathToken, inRange bool) ([]jsonPathNode, []jsonPathToken, error) {
+	var nodes []jsonPathNode
+
+	for len(tokens) > 0 {
+		token := tokens[0]
+		tokens = tokens[1:]
+
+		if !token.isExpr {
+			nodes = append(nodes, jsonPathNode{text: token.value})
+			continue
+		}
+
+		switch {
+		case token.value == "end":
+			if !inRange {
+				return nil, nil, fmt.Errorf("invalid jsonpath template: {end} without {range}")
+			}
+			return nodes, tokens, nil
+
+		case strings.HasPrefix(token.value, "range "):
+			path, err := parsePath(strings.TrimSpace(strings.TrimPrefix(token.value, "range ")))
+			if err != nil {
+				return nil, nil, err
+			}
+			children, rest, err := buildJSONPathNodes(tokens, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, isRange: true, children: children})
+			tokens = rest
+
+		case strings.HasPrefix(token.value, `"`):
+			literal, err := strconv.Unquote(token.value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid jsonpath string literal %s: %w", token.value, err)
+			}
+			nodes = append(nodes, jsonPathNode{text: literal})
+
+		default:
+			path, err := parsePath(token.value)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, jsonPathNode{path: path})
+		}
+	}
+
+	if inRange {
+		return nil, nil, fmt.Errorf("invalid jsonpath template: {range} without {end}")
+	}
+	return nodes, nil, nil
+}
+
+// parsePath parses expressions such as .[*].contactEmail or $.items[0].name
+func parsePath(expr string) ([]pathSegment, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	if expr == "" || expr == "." || expr == "@" {
+		return []pathSegment{}, nil
+	}
+	if expr[0] != '.' && expr[0] != '[' {
+		return nil, fmt.Errorf("invalid jsonpath expression '%s': must start with '.' or '['", expr)
+	}
+
+	var segments []pathSegment
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			field := expr[start:i]
+			if strings.ContainsAny(field, "]*") && field != "*" {
+				return nil, fmt.Errorf("invalid jsonpath field '%s' in '%s'", field, expr)
+			}
+			if field == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+			} else if field != "" {
+				segments = append(segments, pathSegment{field: field})
+			}
+
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath expression '%s': unclosed '['", expr)
+			}
+			inner := strings.TrimSpace(expr[i+1 : i+end])
+			i += end + 1
+
+			if inner == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+				continue
+			}
+			if quoted, err := strconv.Unquote(strings.ReplaceAll(inner, "'", `"`)); err == nil {
+				segments = append(segments, pathSegment{field: quoted})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath index '[%s]' in '%s'", inner, expr)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+
+		default:
+			return nil, fmt.Errorf("invalid jsonpath expression '%s' at position %d", expr, i)
+		}
+	}
+
+	return segments, nil
+}
+
+// Execute renders the template against data after converting it to its JSON form
+func (t *jsonPathTemplate) Execute(w io.Writer, data any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode data for jsonpath: %w", err)
+	}
+
+	var value any
+	if err := json.Unmarshal(jsonData, &value); err != nil {
+		return fmt.Errorf("failed to encode data for jsonpath: %w", err)
+	}
+
+	return executeJSONPathNodes(w, t.nodes, value)
+}
+
+func executeJSONPathNodes(w io.Writer, nodes []jsonPathNode, current any) error {
+	for _, node := range nodes {
+		if node.path == nil {
+			if _, err := io.WriteString(w, node.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		results := evaluatePath(node.path, current)
+
+		if node.isRange {
+			for _, result := range results {
+				if err := executeJSONPathNodes(w, node.children, result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		formatted := make([]string, 0, len(results))
+		for _, result := range results {
+			text, err := formatJSONPathValue(result)
+			if err != nil {
+				return err
+			}
+			formatted = append(formatted, text)
+		}
+		if _, err := io.WriteString(w, strings.Join(formatted, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluatePath applies every segment in turn, fanning out on wildcards
+func evaluatePath(path []pathSegment, root any) []any {
+	current := []any{root}
+
+	for _, segment := range path {
+		var next []any
+		for _, value := range current {
+			switch typed := value.(type) {
+			case map[string]any:
+				if segment.wildcard {
+					for _, key := range sortedKeys(typed) {
+						next = append(next, typed[key])
+					}
+				} else if !segment.isIndex {
+					if child, ok := typed[segment.field]; ok {
+						next = append(next, child)
+					}
+				}
+
+			case []any:
+				if segment.wildcard {
+					next = append(next, typed...)
+				} else if segment.isIndex {
+					index := segment.index
+					if index < 0 {
+						index += len(typed)
+					}
+					if index >= 0 && index < len(typed) {
+						next = append(next, typed[index])
+					}
+				}
+			}
+		}
+		current = next
+	}
+
+	return current
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatJSONPathValue prints strings bare and everything else as compact JSON
+func formatJSONPathValue(value any) (string, error) {
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case nil:
+		return "", nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to format jsonpath result: %w", err)
+	}
+	return string(data), nil
+}
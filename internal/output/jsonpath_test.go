@@ -0,0 +1,135 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/models"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+var jsonPathContacts = []models.Contact{
+	{ID: 1, UserID: "u1", Name: "Jane", Company: ptr("Acme"), ContactEmail: ptr("jane@acme.test")},
+	{ID: 2, UserID: "u1", Name: "John"},
+	{ID: 3, UserID: "u1", Name: "Bob", ContactEmail: ptr("bob@globex.test")},
+}
+
+type jsonPathOwner struct {
+	Name    string          `json:"name"`
+	Manager *models.User    `json:"manager"`
+	Team    []models.User   `json:"team"`
+	Labels  map[string]int  `json:"labels"`
+	Primary *models.Contact `json:"primary"`
+}
+
+func TestJSONPath(t *testing.T) {
+	owner := jsonPathOwner{
+		Name:    "alice",
+		Team:    []models.User{{ID: "u2", Username: "bob"}, {ID: "u3", Username: "carol"}},
+		Labels:  map[string]int{"b": 2, "a": 1},
+		Primary: &jsonPathContacts[0],
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		data     any
+		want     string
+	}{
+		{name: "emails from the request", template: "{.[*].contactEmail}", data: jsonPathContacts, want: "jane@acme.test bob@globex.test"},
+		{name: "dollar root", template: "{$[0].name}", data: jsonPathContacts, want: "Jane"},
+		{name: "negative index", template: "{.[-1].name}", data: jsonPathContacts, want: "Bob"},
+		{name: "number", template: "{.[1].id}", data: jsonPathContacts, want: "2"},
+		{name: "nested field", template: "{.primary.company}", data: owner, want: "Acme"},
+		{name: "nested array", template: "{.team[1].username}", data: owner, want: "carol"},
+		{name: "quoted key", template: "{.team[0]['username']}", data: owner, want: "bob"},
+		{name: "object wildcard is sorted", template: "{.labels.*}", data: owner, want: "1 2"},
+		{name: "object as JSON", template: "{.team[0]}", data: owner, want: `{"id":"u2","username":"bob"}`},
+		{
+			name:     "range",
+			template: `{range .[*]}{.id}{"\t"}{.name}{"\n"}{end}`,
+			data:     jsonPathContacts,
+			want:     "1\tJane\n2\tJohn\n3\tBob\n",
+		},
+		{
+			name:     "nested range",
+			template: `{range .team[*]}[{.username}]{end}`,
+			data:     owner,
+			want:     "[bob][carol]",
+		},
+		{name: "literal text", template: "owner={.name}, first={.team[0].username}!", data: owner, want: "owner=alice, first=bob!"},
+		{name: "braces in string literal", template: `{"{x}"}{.name}`, data: owner, want: "{x}alice"},
+		{name: "missing key", template: "[{.nothing}]", data: owner, want: "[]"},
+		{name: "missing optional field", template: "{.[1].company}", data: jsonPathContacts, want: ""},
+		{name: "nil pointer", template: "[{.manager}]", data: owner, want: "[]"},
+		{name: "through nil pointer", template: "[{.manager.username}]", data: owner, want: "[]"},
+		{name: "index out of range", template: "[{.team[5].username}]", data: owner, want: "[]"},
+		{name: "field of an array", template: "[{.team.username}]", data: owner, want: "[]"},
+		{name: "range over missing", template: "[{range .nothing[*]}x{end}]", data: owner, want: "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseJSONPath(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := tmpl.Execute(&out, tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathInvalid(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{template: "{.name", wantErr: "unclosed '{'"},
+		{template: `{"unterminated}`, wantErr: "unclosed '{'"},
+		{template: "{range .[*]}{.name}", wantErr: "{range} without {end}"},
+		{template: "{.name}{end}", wantErr: "{end} without {range}"},
+		{template: "{name}", wantErr: "must start with '.' or '['"},
+		{template: "{range}", wantErr: "must start with '.' or '['"},
+		{template: "{.team[0}", wantErr: "unclosed '['"},
+		{template: "{.[abc]}", wantErr: "invalid jsonpath index"},
+		{template: "{.[]}", wantErr: "invalid jsonpath index"},
+		{template: `{"\q"}`, wantErr: "invalid jsonpath string literal"},
+		{template: "{.a]}", wantErr: "invalid jsonpath field"},
+		{template: "{.team[0]x}", wantErr: "at position"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := parseJSONPath(tt.template)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONPathPrinter(t *testing.T) {
+	var out bytes.Buffer
+	printer, err := NewTemplate(JSONPath, "{.[*].name}", &out, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := printer.Print(jsonPathContacts, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Jane John Bob" {
+		t.Fatalf("got %q", out.String())
+	}
+
+	if _, err := NewTemplate(JSONPath, "{.name", &out, &out); err == nil {
+		t.Fatal("invalid template accepted")
+	}
+}
@@ -0,0 +1,251 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format selectable with the global --output flag
+type Format string
+
+const (
+	Table  Format = "table"
+	Wide   Format = "wide"
+	JSON   Format = "json"
+	YAML   Format = "yaml"
+	NDJSON Format = "ndjson"
+
+	// GoTemplate and JSONPath take their template after an '=' sign, for example
+	// go-template='{{range .}}{{.Name}}{{"\n"}}{{end}}' or jsonpath='{.[*].name}'
+	GoTemplate Format = "go-template"
+	JSONPath   Format = "jsonpath"
+)
+
+// Formats lists every supported format, in the order shown in help text
+var Formats = []Format{Table, Wide, JSON, YAML, NDJSON, GoTemplate, JSONPath}
+
+// ParseFormat validates a --output value and splits off the template of the
+// go-template=... and jsonpath=... forms. An empty value selects the table format.
+func ParseFormat(value string) (Format, string, error) {
+	if value == "" {
+		return Table, "", nil
+	}
+
+	name, template, hasTemplate := strings.Cut(value, "=")
+	for _, format := range Formats {
+		if !strings.EqualFold(name, string(format)) {
+			continue
+		}
+		if hasTemplate && !format.IsTemplate() {
+			return "", "", fmt.Errorf("output format '%s' does not take a template", format)
+		}
+		return format, template, nil
+	}
+
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", "", fmt.Errorf("unknown output format '%s' (valid formats: %s)", value, strings.Join(names, ", "))
+}
+
+// IsStructured reports whether the format is meant for scripts rather than people.
+// Structured formats write nothing to stdout except the document itself.
+func (f Format) IsStructured() bool {
+	return f == JSON || f == YAML || f == NDJSON || f.IsTemplate()
+}
+
+// IsTemplate reports whether the format renders a user supplied template
+func (f Format) IsTemplate() bool {
+	return f == GoTemplate || f == JSONPath
+}
+
+// Printer renders command results either as a structured document or as a human readable view
+type Printer struct {
+	Format   Format
+	Template string
+	Out      io.Writer
+	Err      io.Writer
+}
+
+// New creates a printer writing documents to out and diagnostics to errOut
+func New(format Format, out, errOut io.Writer) *Printer {
+	return &Printer{
+		Format: format,
+		Out:    out,
+		Err:    errOut,
+	}
+}
+
+// NewTemplate creates a printer for the go-template and jsonpath formats.
+// The template is parsed up front so syntax errors surface before any API call.
+func NewTemplate(format Format, text string, out, errOut io.Writer) (*Printer, error) {
+	if text == "" {
+		return nil, fmt.Errorf("output format '%s' requires a template (use -o %s=... or --template-file)", format, format)
+	}
+
+	printer := New(format, out, errOut)
+	printer.Template = text
+
+	var err error
+	switch format {
+	case GoTemplate:
+		_, err = parseGoTemplate(text)
+	case JSONPath:
+		_, err = parseJSONPath(text)
+	default:
+		err = fmt.Errorf("output format '%s' does not take a template", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return printer, nil
+}
+
+// Messages returns where informational messages go: stdout for people, stderr when
+// stdout is reserved for a structured document
+func (p *Printer) Messages() io.Writer {
+	if p.IsStructured() {
+		return p.Err
+	}
+	return p.Out
+}
+
+// IsStructured reports whether the printer emits a machine readable document
+func (p *Printer) IsStructured() bool {
+	return p.Format.IsStructured()
+}
+
+// IsWide reports whether human readable output should include every column untruncated
+func (p *Printer) IsWide() bool {
+	return p.Format == Wide
+}
+
+// Print writes data as a structured document, or calls human to render the table view
+func (p *Printer) Print(data any, human func()) error {
+	switch p.Format {
+	case JSON:
+		return writeJSON(p.Out, data)
+	case YAML:
+		return writeYAML(p.Out, data)
+	case NDJSON:
+		return writeNDJSON(p.Out, data)
+	case GoTemplate:
+		return writeGoTemplate(p.Out, p.Template, data)
+	case JSONPath:
+		return writeJSONPath(p.Out, p.Template, data)
+	default:
+		if human != nil {
+			human()
+		}
+		return nil
+	}
+}
+
+func writeJSON(w io.Writer, data any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(normalize(data)); err != nil {
+		return fmt.Errorf("failed to encode JSON output: %w", err)
+	}
+	return nil
+}
+
+// writeNDJSON writes one compact JSON document per line, one per element when data is a slice
+func writeNDJSON(w io.Writer, data any) error {
+	encoder := json.NewEncoder(w)
+
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice {
+		if err := encoder.Encode(data); err != nil {
+			return fmt.Errorf("failed to encode NDJSON output: %w", err)
+		}
+		return nil
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		if err := encoder.Encode(value.Index(i).Interface()); err != nil {
+			return fmt.Errorf("failed to encode NDJSON output: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeYAML encodes data through its JSON form so the YAML keys match the JSON field names
+func writeYAML(w io.Writer, data any) error {
+	jsonData, err := json.Marshal(normalize(data))
+	if err != nil {
+		return fmt.Errorf("failed to encode YAML output: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonData, &node); err != nil {
+		return fmt.Errorf("failed to encode YAML output: %w", err)
+	}
+	resetStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode YAML output: %w", err)
+	}
+	return encoder.Close()
+}
+
+func parseGoTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid go-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeGoTemplate executes the template against the Go values, so fields use their
+// Go names such as {{.ID}} and {{.ContactEmail}}
+func writeGoTemplate(w io.Writer, text string, data any) error {
+	tmpl, err := parseGoTemplate(text)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, normalize(data)); err != nil {
+		return fmt.Errorf("failed to execute go-template: %w", err)
+	}
+	return nil
+}
+
+// writeJSONPath evaluates the template against the JSON form of the data, so fields
+// use their JSON names such as {.id} and {.contactEmail}
+func writeJSONPath(w io.Writer, text string, data any) error {
+	tmpl, err := parseJSONPath(text)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, normalize(data)); err != nil {
+		return fmt.Errorf("failed to execute jsonpath: %w", err)
+	}
+	return nil
+}
+
+// resetStyle drops the flow and quoting styles inherited from the JSON source
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// normalize turns nil slices into empty ones so lists are always encoded as [] rather than null
+func normalize(data any) any {
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+	return data
+}
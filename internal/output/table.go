@@ -0,0 +1,225 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	columnSeparator = " | "
+	ellipsis        = "..."
+	minColumnWidth  = 5
+)
+
+// Column describes one column of a table of T values
+type Column[T any] struct {
+	Name     string         // key used by --columns and --sort-by
+	Header   string         // title printed in the header row
+	Value    func(T) string // cell text for a row
+	Numeric  bool           // sort by numeric value instead of text
+	MaxWidth int            // truncate longer cells unless wide output is requested; 0 means no limit
+	Wide     bool           // only shown by default in wide output
+}
+
+// TableOptions controls which columns are shown and how rows are ordered
+type TableOptions struct {
+	Columns   []string // column names to show, in order; empty selects the defaults
+	SortBy    string   // column name to sort by, prefixed with '-' for descending order
+	NoHeaders bool     // omit the header rows
+	Wide      bool     // show wide columns and never truncate
+	Width     int      // total width to fit the table into; 0 means unlimited
+}
+
+// TablePrinter renders rows of T with a validated set of columns
+type TablePrinter[T any] struct {
+	columns    []Column[T]
+	sortColumn *Column[T]
+	descending bool
+	options    TableOptions
+}
+
+// NewTablePrinter validates the options against the available columns
+func NewTablePrinter[T any](columns []Column[T], options TableOptions) (*TablePrinter[T], error) {
+	table := &TablePrinter[T]{options: options}
+
+	byName := make(map[string]Column[T], len(columns))
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		byName[column.Name] = column
+		names[i] = column.Name
+	}
+
+	if len(options.Columns) > 0 {
+		for _, name := range options.Columns {
+			column, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("unknown column '%s' (valid columns: %s)", name, strings.Join(names, ", "))
+			}
+			table.columns = append(table.columns, column)
+		}
+	} else {
+		for _, column := range columns {
+			if !column.Wide || options.Wide {
+				table.columns = append(table.columns, column)
+			}
+		}
+	}
+
+	if options.SortBy != "" {
+		name := strings.ToLower(options.SortBy)
+		if strings.HasPrefix(name, "-") {
+			table.descending = true
+			name = name[1:]
+		}
+		column, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort column '%s' (valid columns: %s)", options.SortBy, strings.Join(names, ", "))
+		}
+		table.sortColumn = &column
+	}
+
+	return table, nil
+}
+
+// Sort orders rows in place by the --sort-by column. Rows keep their order when no
+// sort column was given or when values are equal.
+func (t *TablePrinter[T]) Sort(rows []T) {
+	if t.sortColumn == nil {
+		return
+	}
+
+	column := t.sortColumn
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := column.Value(rows[i]), column.Value(rows[j])
+		if t.descending {
+			a, b = b, a
+		}
+		if column.Numeric {
+			numA, errA := strconv.ParseFloat(a, 64)
+			numB, errB := strconv.ParseFloat(b, 64)
+			if errA == nil && errB == nil {
+				return numA < numB
+			}
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+}
+
+// Render writes the rows as an aligned table
+func (t *TablePrinter[T]) Render(w io.Writer, rows []T) {
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(t.columns))
+		for j, column := range t.columns {
+			cells[i][j] = strings.ReplaceAll(column.Value(row), "\n", " ")
+		}
+	}
+
+	widths := t.columnWidths(cells)
+
+	if !t.options.NoHeaders {
+		headers := make([]string, len(t.columns))
+		dashes := make([]string, len(t.columns))
+		for i, column := range t.columns {
+			headers[i] = column.Header
+			dashes[i] = strings.Repeat("-", widths[i])
+		}
+		writeRow(w, headers, widths)
+		writeRow(w, dashes, widths)
+	}
+
+	for _, row := range cells {
+		writeRow(w, row, widths)
+	}
+}
+
+// columnWidths sizes every column to its content, applies the per column limits and then
+// shrinks the widest columns until the table fits the available width
+func (t *TablePrinter[T]) columnWidths(cells [][]string) []int {
+	widths := make([]int, len(t.columns))
+	for i, column := range t.columns {
+		if !t.options.NoHeaders {
+			widths[i] = utf8.RuneCountInString(column.Header)
+		}
+		for _, row := range cells {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+		if !t.options.Wide && column.MaxWidth > 0 {
+			widths[i] = min(widths[i], column.MaxWidth)
+		}
+	}
+
+	if t.options.Wide || t.options.Width <= 0 {
+		return widths
+	}
+
+	for total(widths) > t.options.Width {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+	}
+
+	return widths
+}
+
+func total(widths []int) int {
+	sum := len(columnSeparator) * (len(widths) - 1)
+	for _, width := range widths {
+		sum += width
+	}
+	return sum
+}
+
+// writeRow pads every cell to its column width; the last column is not padded
+func writeRow(w io.Writer, cells []string, widths []int) {
+	var line strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			line.WriteString(columnSeparator)
+		}
+		cell = Truncate(cell, widths[i])
+		line.WriteString(cell)
+		if i < len(cells)-1 {
+			line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+	}
+	fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+}
+
+// Truncate shortens s to at most width runes, marking the cut with an ellipsis.
+// It never splits a multibyte character.
+func Truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= len(ellipsis) {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-len(ellipsis)]) + ellipsis
+}
+
+// TerminalWidth returns the width of the terminal attached to stdout, falling back to
+// $COLUMNS. It returns 0 when output is not going to a terminal.
+func TerminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
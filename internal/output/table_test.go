@@ -0,0 +1,197 @@
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+type tableRow struct {
+	id   int
+	name string
+	note string
+}
+
+var tableColumns = []Column[tableRow]{
+	{Name: "id", Header: "ID", Numeric: true, Value: func(r tableRow) string { return strconv.Itoa(r.id) }},
+	{Name: "name", Header: "Name", MaxWidth: 10, Value: func(r tableRow) string { return r.name }},
+	{Name: "note", Header: "Note", Wide: true, Value: func(r tableRow) string { return r.note }},
+}
+
+var tableRows = []tableRow{
+	{id: 2, name: "bob", note: "second"},
+	{id: 10, name: "Alice", note: "tenth"},
+	{id: 1, name: "carol", note: "first"},
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  string
+	}{
+		{name: "fits", input: "Jane", width: 4, want: "Jane"},
+		{name: "ascii", input: "Jane Doe", width: 6, want: "Jan..."},
+		{name: "multibyte at the cut", input: "Zoë Ångström", width: 6, want: "Zoë..."},
+		{name: "multibyte fits", input: "Zoë", width: 3, want: "Zoë"},
+		{name: "emoji at the cut", input: "🎉🎉🎉🎉🎉🎉", width: 5, want: "🎉🎉..."},
+		{name: "cjk", input: "東京都千代田区", width: 4, want: "東..."},
+		{name: "narrower than the ellipsis", input: "日本語テキスト", width: 2, want: "日本"},
+		{name: "zero width", input: "abc", width: 0, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.input, tt.width)
+			if got != tt.want {
+				t.Fatalf("Truncate(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("Truncate(%q, %d) split a character: %q", tt.input, tt.width, got)
+			}
+		})
+	}
+}
+
+func renderTable(t *testing.T, options TableOptions, rows []tableRow) []string {
+	t.Helper()
+	table, err := NewTablePrinter(tableColumns, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows = append([]tableRow(nil), rows...)
+	table.Sort(rows)
+
+	var out bytes.Buffer
+	table.Render(&out, rows)
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func firstCells(lines []string) []string {
+	var cells []string
+	for _, line := range lines {
+		cell, _, _ := strings.Cut(line, columnSeparator)
+		cells = append(cells, strings.TrimSpace(cell))
+	}
+	return cells
+}
+
+func TestTableSort(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		want   string
+	}{
+		{sortBy: "", want: "2,10,1"},
+		{sortBy: "id", want: "1,2,10"},
+		{sortBy: "-id", want: "10,2,1"},
+		{sortBy: "name", want: "10,2,1"},
+		{sortBy: "-NAME", want: "1,2,10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			lines := renderTable(t, TableOptions{SortBy: tt.sortBy, NoHeaders: true}, tableRows)
+			if got := strings.Join(firstCells(lines), ","); got != tt.want {
+				t.Fatalf("got order %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options TableOptions
+		wantErr string
+	}{
+		{name: "unknown column", options: TableOptions{Columns: []string{"id", "email"}}, wantErr: "unknown column 'email'"},
+		{name: "unknown sort column", options: TableOptions{SortBy: "-email"}, wantErr: "unknown sort column '-email'"},
+		{name: "columns ignore case and spaces", options: TableOptions{Columns: []string{" NAME ", "id"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTablePrinter(tableColumns, tt.options)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableRender(t *testing.T) {
+	long := []tableRow{{id: 1, name: "Bartholomew Oberbrunner", note: "a note that is rather long"}}
+
+	tests := []struct {
+		name    string
+		options TableOptions
+		rows    []tableRow
+		want    []string
+	}{
+		{
+			name: "headers",
+			rows: tableRows[:1],
+			want: []string{"ID | Name", "-- | ----", "2  | bob"},
+		},
+		{
+			name:    "no headers",
+			options: TableOptions{NoHeaders: true},
+			rows:    tableRows[:1],
+			want:    []string{"2 | bob"},
+		},
+		{
+			name:    "selected columns",
+			options: TableOptions{Columns: []string{"note", "id"}, NoHeaders: true},
+			rows:    tableRows[:1],
+			want:    []string{"second | 2"},
+		},
+		{
+			name: "max width",
+			rows: long,
+			want: []string{"ID | Name", "-- | ----------", "1  | Barthol..."},
+		},
+		{
+			name:    "shrunk to the terminal width",
+			options: TableOptions{Columns: []string{"id", "name", "note"}, Width: 30},
+			rows:    long,
+			want:    []string{"ID | Name       | Note", "-- | ---------- | ------------", "1  | Barthol... | a note th..."},
+		},
+		{
+			name:    "wide ignores limits",
+			options: TableOptions{Wide: true, Width: 30},
+			rows:    long,
+			want: []string{
+				"ID | Name                    | Note",
+				"-- | ----------------------- | --------------------------",
+				"1  | Bartholomew Oberbrunner | a note that is rather long",
+			},
+		},
+		{
+			name:    "multibyte cells align",
+			options: TableOptions{NoHeaders: true},
+			rows:    []tableRow{{id: 1, name: "Zoë"}, {id: 2, name: "Bob"}},
+			want:    []string{"1 | Zoë", "2 | Bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderTable(t, tt.options, tt.rows)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if tt.options.Width > 0 && !tt.options.Wide {
+				for _, line := range got {
+					if n := utf8.RuneCountInString(line); n > tt.options.Width {
+						t.Fatalf("line %q is %d wide, more than %d", line, n, tt.options.Width)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"crm-admin/internal/export"
+	"crm-admin/internal/models"
+)
+
+// Field is a searchable field of a contact or note
+type Field string
+
+const (
+	FieldName        Field = "name"
+	FieldCompany     Field = "company"
+	FieldPhone       Field = "phone"
+	FieldEmail       Field = "email"
+	FieldTitle       Field = "title"
+	FieldDescription Field = "description"
+)
+
+// Fields lists every searchable field, contact fields first
+var Fields = []Field{FieldName, FieldCompany, FieldPhone, FieldEmail, FieldTitle, FieldDescription}
+
+var fieldAliases = map[string]Field{
+	"desc":         FieldDescription,
+	"phonenumber":  FieldPhone,
+	"contactemail": FieldEmail,
+}
+
+// Types of results
+const (
+	TypeContact = "contact"
+	TypeNote    = "note"
+)
+
+// Options control how the values of a query are matched
+type Options struct {
+	Regex         bool // values are regular expressions instead of text
+	CaseSensitive bool
+}
+
+// Query is a parsed search query. A record matches when every term matches at least
+// one of its fields.
+type Query struct {
+	terms []term
+	types map[string]bool // nil for all types
+}
+
+type term struct {
+	field   Field // empty for any field
+	pattern *regexp.Regexp
+}
+
+// Parse parses a query of whitespace separated terms. A term is matched against
+// every field, or with a "field:" prefix only against that field; type:contact and
+// type:note restrict the results to one type. Double quotes keep spaces in a term,
+// as in name:"jane doe", and a quoted term is never a field, as in "todo: call".
+// Unless options.Regex is set, values match as text anywhere in a field, and values
+// with * or ? are wildcards that must match the whole field.
+func Parse(query string, options Options) (*Query, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the search query is empty")
+	}
+
+	q := &Query{}
+	for _, token := range tokens {
+		var field Field
+		value := token.text
+		if name, rest, ok := strings.Cut(token.text, ":"); ok && !token.quoted && isWord(name) {
+			if strings.EqualFold(name, "type") {
+				if err := q.addType(rest); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if field, err = parseField(name); err != nil {
+				return nil, err
+			}
+			value = rest
+		}
+		if value == "" {
+			return nil, fmt.Errorf("term '%s' has no value", token.text)
+		}
+
+		pattern, err := compile(value, options)
+		if err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, term{field: field, pattern: pattern})
+	}
+	if len(q.terms) == 0 {
+		return nil, fmt.Errorf("the search query has nothing to search for besides the type")
+	}
+	return q, nil
+}
+
+// isWord reports whether s only has letters, so "10:30" isn't taken for a field
+func isWord(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) < 0
+}
+
+func parseField(name string) (Field, error) {
+	name = strings.ToLower(name)
+	for _, field := range Fields {
+		if string(field) == name {
+			return field, nil
+		}
+	}
+	if field, ok := fieldAliases[name]; ok {
+		return field, nil
+	}
+	return "", fmt.Errorf("unknown field '%s' (use one of: name, company, phone, email, title, description)", name)
+}
+
+func (q *Query) addType(value string) error {
+	value = strings.TrimSuffix(strings.ToLower(value), "s")
+	if value != TypeContact && value != TypeNote {
+		return fmt.Errorf("unknown type '%s' (use contact or note)", value)
+	}
+	if q.types == nil {
+		q.types = make(map[string]bool)
+	}
+	q.types[value] = true
+	return nil
+}
+
+// compile turns a query value into a regular expression
+func compile(value string, options Options) (*regexp.Regexp, error) {
+	expr := value
+	switch {
+	case options.Regex:
+	case strings.ContainsAny(value, "*?"):
+		var b strings.Builder
+		b.WriteString("^")
+		for _, r := range value {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		expr = b.String()
+	default:
+		expr = regexp.QuoteMeta(value)
+	}
+	if !options.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression '%s': %w", value, err)
+	}
+	return pattern, nil
+}
+
+type token struct {
+	text   string
+	quoted bool // the token started with a quote
+}
+
+// tokenize splits a query on whitespace outside of double quotes and drops the quotes
+func tokenize(query string) ([]token, error) {
+	var (
+		tokens  []token
+		current token
+		started bool
+		quoted  bool
+	)
+	for _, r := range query {
+		switch {
+		case r == '"':
+			if !started {
+				current.quoted = true
+			}
+			quoted = !quoted
+			started = true
+		case !quoted && unicode.IsSpace(r):
+			if started {
+				tokens = append(tokens, current)
+				current, started = token{}, false
+			}
+		default:
+			current.text += string(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in search query")
+	}
+	if started {
+		tokens = append(tokens, current)
+	}
+	return tokens, nil
+}
+
+// Match is a field of a result and where the query matched in it
+type Match struct {
+	Field  Field    `json:"field"`
+	Value  string   `json:"value"`
+	Ranges [][2]int `json:"ranges"` // byte offsets of the matched parts, sorted and not overlapping
+}
+
+// Hit is a contact or note that matched a query
+type Hit struct {
+	Type     string  `json:"type"`
+	ID       int     `json:"id"`
+	UserID   string  `json:"userId"`
+	Username string  `json:"username,omitempty"`
+	Label    string  `json:"label"` // the name of a contact or title of a note
+	Matches  []Match `json:"matches"`
+}
+
+// Results are the hits of a query grouped by type
+type Results struct {
+	Contacts []Hit `json:"contacts"`
+	Notes    []Hit `json:"notes"`
+}
+
+// Search returns the contacts and notes of dataset that match the query
+func (q *Query) Search(dataset *export.Dataset) Results {
+	results := Results{Contacts: []Hit{}, Notes: []Hit{}}
+	if q.wants(TypeContact) {
+		for _, contact := range dataset.Contacts {
+			if matches, ok := q.match(contactFields(contact)); ok {
+				results.Contacts = append(results.Contacts, Hit{
+					Type: TypeContact, ID: contact.ID, UserID: contact.UserID, Username: dataset.User.Username,
+					Label: contact.Name, Matches: matches,
+				})
+			}
+		}
+	}
+	if q.wants(TypeNote) {
+		for _, note := range dataset.Notes {
+			if matches, ok := q.match(noteFields(note)); ok {
+				results.Notes = append(results.Notes, Hit{
+					Type: TypeNote, ID: note.ID, UserID: note.UserID, Username: dataset.User.Username,
+					Label: note.Title, Matches: matches,
+				})
+			}
+		}
+	}
+	return results
+}
+
+func (q *Query) wants(resultType string) bool {
+	return q.types == nil || q.types[resultType]
+}
+
+type fieldValue struct {
+	field Field
+	value string
+}
+
+func contactFields(contact models.Contact) []fieldValue {
+	return []fieldValue{
+		{FieldName, contact.Name},
+		{FieldCompany, value(contact.Company)},
+		{FieldPhone, value(contact.PhoneNumber)},
+		{FieldEmail, value(contact.ContactEmail)},
+	}
+}
+
+func noteFields(note models.Note) []fieldValue {
+	return []fieldValue{
+		{FieldTitle, note.Title},
+		{FieldDescription, value(note.Description)},
+	}
+}
+
+// match checks every term against the fields and collects what matched where
+func (q *Query) match(fields []fieldValue) ([]Match, bool) {
+	ranges := make(map[Field][][2]int)
+	for _, t := range q.terms {
+		matched := false
+		for _, f := range fields {
+			if f.value == "" || (t.field != "" && t.field != f.field) {
+				continue
+			}
+			for _, loc := range t.pattern.FindAllStringIndex(f.value, -1) {
+				if loc[0] < loc[1] {
+					ranges[f.field] = append(ranges[f.field], [2]int{loc[0], loc[1]})
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return nil, false
+		}
+	}
+
+	var matches []Match
+	for _, f := range fields {
+		if r, ok := ranges[f.field]; ok {
+			matches = append(matches, Match{Field: f.field, Value: f.value, Ranges: mergeRanges(r)})
+		}
+	}
+	return matches, true
+}
+
+func mergeRanges(ranges [][2]int) [][2]int {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := [][2]int{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			last[1] = max(last[1], r[1])
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// Highlight wraps the matched parts of a match's value in start and end
+func (m Match) Highlight(start, end string) string {
+	var b strings.Builder
+	previous := 0
+	for _, r := range m.Ranges {
+		b.WriteString(m.Value[previous:r[0]])
+		b.WriteString(start)
+		b.WriteString(m.Value[r[0]:r[1]])
+		b.WriteString(end)
+		previous = r[1]
+	}
+	b.WriteString(m.Value[previous:])
+	return b.String()
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
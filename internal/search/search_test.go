@@ -0,0 +1,106 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/export"
+	"crm-admin/internal/models"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+var dataset = &export.Dataset{
+	User: models.User{ID: "u1", Username: "alice"},
+	Contacts: []models.Contact{
+		{ID: 1, UserID: "u1", Name: "Jane Doe", Company: ptr("Acme Corp"), ContactEmail: ptr("jane@gmail.com")},
+		{ID: 2, UserID: "u1", Name: "John Acme", Company: ptr("Globex"), ContactEmail: ptr("john@globex.test"), PhoneNumber: ptr("+44 20 7946 0000")},
+		{ID: 3, UserID: "u1", Name: "Bob", Company: ptr("ACME"), ContactEmail: ptr("bob@GMAIL.com")},
+	},
+	Notes: []models.Note{
+		{ID: 10, UserID: "u1", Title: "Call Acme", Description: ptr("follow up at 10:30")},
+		{ID: 11, UserID: "u1", Title: "Lunch", Description: ptr("with jane doe")},
+	},
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		options      Options
+		wantContacts []int
+		wantNotes    []int
+		wantErr      string
+	}{
+		{name: "any field", query: "acme", wantContacts: []int{1, 2, 3}, wantNotes: []int{10}},
+		{name: "field scoped", query: "company:acme", wantContacts: []int{1, 3}},
+		{name: "all terms", query: "company:acme email:*@gmail.com", wantContacts: []int{1, 3}},
+		{name: "wildcard matches whole field", query: "email:*@gmail", wantContacts: nil},
+		{name: "quoted phrase", query: `"jane doe"`, wantContacts: []int{1}, wantNotes: []int{11}},
+		{name: "quoted field value", query: `description:"jane doe"`, wantNotes: []int{11}},
+		{name: "colon in text", query: "10:30", wantNotes: []int{10}},
+		{name: "type filter", query: "type:note acme", wantNotes: []int{10}},
+		{name: "case sensitive", query: "company:ACME", options: Options{CaseSensitive: true}, wantContacts: []int{3}},
+		{name: "regex", query: `phone:^\+44 name:^j`, options: Options{Regex: true}, wantContacts: []int{2}},
+		{name: "regex is literal without flag", query: "^j", wantContacts: nil},
+		{name: "unknown field", query: "city:london", wantErr: "unknown field"},
+		{name: "unknown type", query: "type:deal acme", wantErr: "unknown type"},
+		{name: "empty value", query: "email:", wantErr: "no value"},
+		{name: "only type", query: "type:note", wantErr: "nothing to search"},
+		{name: "unterminated quote", query: `"jane`, wantErr: "unterminated"},
+		{name: "invalid regex", query: "(", options: Options{Regex: true}, wantErr: "invalid regular expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := Parse(tt.query, tt.options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := query.Search(dataset)
+			if got := ids(results.Contacts); !reflect.DeepEqual(got, tt.wantContacts) {
+				t.Errorf("got contacts %v, want %v", got, tt.wantContacts)
+			}
+			if got := ids(results.Notes); !reflect.DeepEqual(got, tt.wantNotes) {
+				t.Errorf("got notes %v, want %v", got, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func ids(hits []Hit) []int {
+	var ids []int
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestHighlight(t *testing.T) {
+	query, err := Parse("ac cme jane", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := query.Search(dataset)
+	if len(results.Contacts) != 1 {
+		t.Fatalf("got %+v", results.Contacts)
+	}
+
+	var got []string
+	for _, match := range results.Contacts[0].Matches {
+		got = append(got, string(match.Field)+"="+match.Highlight("[", "]"))
+	}
+	want := []string{"name=[Jane] Doe", "company=[Acme] Corp", "email=[jane]@gmail.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
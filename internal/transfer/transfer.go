@@ -0,0 +1,190 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"crm-admin/internal/api"
+	"crm-admin/internal/models"
+)
+
+// Plan is what a transfer of contacts from one user to another will do
+type Plan struct {
+	From     models.User      `json:"from"`
+	To       models.User      `json:"to"`
+	Contacts []models.Contact `json:"contacts"`
+
+	// MovedNotes only link transferred contacts; they are recreated for the target
+	// user and, with DeleteOriginals, deleted from the source user
+	MovedNotes []models.Note `json:"movedNotes"`
+
+	// SharedNotes also link contacts that stay; the target user gets a copy linking
+	// the transferred contacts, and with DeleteOriginals the source note is unlinked
+	// from them
+	SharedNotes []models.Note `json:"sharedNotes"`
+
+	DeleteOriginals bool `json:"deleteOriginals"`
+}
+
+// NewPlan works out the transfer of the given contacts, or of all contacts when
+// contactIDs is nil, from one user to another
+func NewPlan(ctx context.Context, service api.CRMService, from, to models.User, contactIDs []int, deleteOriginals bool) (*Plan, error) {
+	if from.ID == to.ID {
+		return nil, fmt.Errorf("the source and target user are the same")
+	}
+
+	contacts, err := service.ListContacts(ctx, from.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	notes, err := service.ListNotesForUser(ctx, from.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	plan := &Plan{From: from, To: to, Contacts: []models.Contact{}, MovedNotes: []models.Note{}, SharedNotes: []models.Note{}, DeleteOriginals: deleteOriginals}
+	selected := make(map[int]bool)
+	for _, contact := range contacts {
+		if contactIDs == nil || slices.Contains(contactIDs, contact.ID) {
+			plan.Contacts = append(plan.Contacts, contact)
+			selected[contact.ID] = true
+		}
+	}
+	for _, id := range contactIDs {
+		if !selected[id] {
+			return nil, fmt.Errorf("contact %d does not belong to user %s", id, from.ID)
+		}
+	}
+	slices.SortFunc(plan.Contacts, func(a, b models.Contact) int { return a.ID - b.ID })
+
+	slices.SortFunc(notes, func(a, b models.Note) int { return a.ID - b.ID })
+	for _, note := range notes {
+		linked, staying := 0, 0
+		for _, id := range note.ContactIDs {
+			if selected[id] {
+				linked++
+			} else {
+				staying++
+			}
+		}
+		switch {
+		case linked == 0:
+		case staying == 0:
+			plan.MovedNotes = append(plan.MovedNotes, note)
+		default:
+			plan.SharedNotes = append(plan.SharedNotes, note)
+		}
+	}
+	return plan, nil
+}
+
+// Result is what a transfer did. The maps go from source IDs to target IDs.
+type Result struct {
+	Contacts        map[int]int `json:"contacts"`
+	Notes           map[int]int `json:"notes"`
+	DeletedContacts []int       `json:"deletedContacts,omitempty"`
+	DeletedNotes    []int       `json:"deletedNotes,omitempty"`
+	UnlinkedNotes   []int       `json:"unlinkedNotes,omitempty"` // shared source notes unlinked from the transferred contacts
+	RolledBack      bool        `json:"rolledBack,omitempty"`
+}
+
+// Execute carries out a plan. The contacts and notes are first recreated for the
+// target user; if any of that fails, everything created so far is deleted again.
+// Only then are the originals removed, when the plan says so. A failure while
+// removing them keeps the copies, and the returned error says what is left.
+func Execute(ctx context.Context, service api.CRMService, plan *Plan) (*Result, error) {
+	result := &Result{Contacts: make(map[int]int), Notes: make(map[int]int)}
+	var undo []func(context.Context) error
+
+	copyErr := func() error {
+		for _, contact := range plan.Contacts {
+			created, err := service.CreateContact(ctx, contact.Name, plan.To.ID, contact.Company, contact.PhoneNumber, contact.ContactEmail)
+			if err != nil {
+				return fmt.Errorf("failed to recreate contact %d: %w", contact.ID, err)
+			}
+			result.Contacts[contact.ID] = created.ID
+			undo = append(undo, func(ctx context.Context) error { return service.DeleteContact(ctx, plan.To.ID, created.ID) })
+		}
+
+		for _, note := range append(slices.Clone(plan.MovedNotes), plan.SharedNotes...) {
+			var contactIDs []int
+			for _, id := range note.ContactIDs {
+				if newID, ok := result.Contacts[id]; ok {
+					contactIDs = append(contactIDs, newID)
+				}
+			}
+			created, err := service.CreateNote(ctx, note.Title, value(note.Description), contactIDs, plan.To.ID)
+			if err != nil {
+				return fmt.Errorf("failed to recreate note %d: %w", note.ID, err)
+			}
+			result.Notes[note.ID] = created.ID
+			undo = append(undo, func(ctx context.Context) error { return service.DeleteNote(ctx, plan.To.ID, created.ID) })
+		}
+		return nil
+	}()
+
+	if copyErr != nil {
+		// Roll back even when the failure was an interrupt
+		rollbackCtx := context.WithoutCancel(ctx)
+		var rollbackErrs []error
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](rollbackCtx); err != nil {
+				rollbackErrs = append(rollbackErrs, err)
+			}
+		}
+		if len(rollbackErrs) > 0 {
+			return result, fmt.Errorf("%w; rolling back failed, remove the copies by hand: %w", copyErr, errors.Join(rollbackErrs...))
+		}
+		result.Contacts = map[int]int{}
+		result.Notes = map[int]int{}
+		result.RolledBack = true
+		return result, fmt.Errorf("%w; the transfer was rolled back", copyErr)
+	}
+
+	if !plan.DeleteOriginals {
+		return result, nil
+	}
+
+	transferred := make(map[int]bool)
+	for _, contact := range plan.Contacts {
+		transferred[contact.ID] = true
+	}
+	leftover := func(err error) error {
+		return fmt.Errorf("%w; the contacts were copied to user %s, but not all originals were removed from user %s", err, plan.To.ID, plan.From.ID)
+	}
+
+	for _, note := range plan.SharedNotes {
+		var staying []int
+		for _, id := range note.ContactIDs {
+			if !transferred[id] {
+				staying = append(staying, id)
+			}
+		}
+		if _, err := service.UpdateNote(ctx, plan.From.ID, note.ID, note.Title, value(note.Description), staying); err != nil {
+			return result, leftover(fmt.Errorf("failed to unlink note %d: %w", note.ID, err))
+		}
+		result.UnlinkedNotes = append(result.UnlinkedNotes, note.ID)
+	}
+	for _, note := range plan.MovedNotes {
+		if err := service.DeleteNote(ctx, plan.From.ID, note.ID); err != nil {
+			return result, leftover(fmt.Errorf("failed to delete note %d: %w", note.ID, err))
+		}
+		result.DeletedNotes = append(result.DeletedNotes, note.ID)
+	}
+	for _, contact := range plan.Contacts {
+		if err := service.DeleteContact(ctx, plan.From.ID, contact.ID); err != nil {
+			return result, leftover(fmt.Errorf("failed to delete contact %d: %w", contact.ID, err))
+		}
+		result.DeletedContacts = append(result.DeletedContacts, contact.ID)
+	}
+	return result, nil
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"crm-admin/internal/fake"
+	"crm-admin/internal/models"
+)
+
+// failingNotes fails to create notes, to test the rollback
+type failingNotes struct {
+	*fake.Service
+}
+
+func (f failingNotes) CreateNote(ctx context.Context, title, description string, contactIDs []int, userID string) (*models.Note, error) {
+	return nil, errors.New("backend unavailable")
+}
+
+type fixture struct {
+	service                *fake.Service
+	alice, bob             *models.User
+	jane, john, stays      *models.Contact
+	moved, shared, ignored *models.Note
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := t.Context()
+	f := &fixture{service: fake.New()}
+	f.alice, _ = f.service.CreateUser(ctx, "alice", "secret")
+	f.bob, _ = f.service.CreateUser(ctx, "bob", "secret")
+	f.jane, _ = f.service.CreateContact(ctx, "Jane", f.alice.ID, nil, nil, nil)
+	f.john, _ = f.service.CreateContact(ctx, "John", f.alice.ID, nil, nil, nil)
+	f.stays, _ = f.service.CreateContact(ctx, "Stays", f.alice.ID, nil, nil, nil)
+	f.moved, _ = f.service.CreateNote(ctx, "Moved", "only jane and john", []int{f.jane.ID, f.john.ID}, f.alice.ID)
+	f.shared, _ = f.service.CreateNote(ctx, "Shared", "", []int{f.jane.ID, f.stays.ID}, f.alice.ID)
+	f.ignored, _ = f.service.CreateNote(ctx, "Ignored", "", []int{f.stays.ID}, f.alice.ID)
+	return f
+}
+
+func TestNewPlan(t *testing.T) {
+	f := newFixture(t)
+	ctx := t.Context()
+
+	plan, err := NewPlan(ctx, f.service, *f.alice, *f.bob, []int{f.jane.ID, f.john.ID}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Contacts) != 2 || len(plan.MovedNotes) != 1 || plan.MovedNotes[0].ID != f.moved.ID ||
+		len(plan.SharedNotes) != 1 || plan.SharedNotes[0].ID != f.shared.ID {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	all, err := NewPlan(ctx, f.service, *f.alice, *f.bob, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Contacts) != 3 || len(all.MovedNotes) != 3 || len(all.SharedNotes) != 0 {
+		t.Fatalf("unexpected plan for all contacts: %+v", all)
+	}
+
+	if _, err := NewPlan(ctx, f.service, *f.alice, *f.bob, []int{999}, false); err == nil || !strings.Contains(err.Error(), "does not belong") {
+		t.Fatalf("got error %v, want an unknown contact", err)
+	}
+	if _, err := NewPlan(ctx, f.service, *f.alice, *f.alice, nil, false); err == nil {
+		t.Fatal("expected an error for the same user")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name            string
+		deleteOriginals bool
+	}{
+		{name: "copy"},
+		{name: "move", deleteOriginals: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := t.Context()
+
+			plan, _ := NewPlan(ctx, f.service, *f.alice, *f.bob, []int{f.jane.ID, f.john.ID}, tt.deleteOriginals)
+			result, err := Execute(ctx, f.service, plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bobNotes, _ := f.service.ListNotesForUser(ctx, f.bob.ID)
+			for _, note := range bobNotes {
+				want := []int{result.Contacts[f.jane.ID], result.Contacts[f.john.ID]}
+				if note.Title == "Shared" {
+					want = []int{result.Contacts[f.jane.ID]}
+				}
+				ids := slices.Clone(note.ContactIDs)
+				slices.Sort(ids)
+				if !slices.Equal(ids, want) {
+					t.Fatalf("note %q links %v, want %v", note.Title, note.ContactIDs, want)
+				}
+			}
+			if len(bobNotes) != 2 {
+				t.Fatalf("bob has %d notes, want 2", len(bobNotes))
+			}
+
+			aliceContacts, _ := f.service.ListContacts(ctx, f.alice.ID)
+			aliceNotes, _ := f.service.ListNotesForUser(ctx, f.alice.ID)
+			wantContacts, wantNotes := 3, 3
+			if tt.deleteOriginals {
+				wantContacts, wantNotes = 1, 2
+			}
+			if len(aliceContacts) != wantContacts || len(aliceNotes) != wantNotes {
+				t.Fatalf("alice has %d contacts and %d notes, want %d and %d", len(aliceContacts), len(aliceNotes), wantContacts, wantNotes)
+			}
+			if tt.deleteOriginals {
+				shared, _ := f.service.GetNote(ctx, f.alice.ID, f.shared.ID)
+				if !slices.Equal(shared.ContactIDs, []int{f.stays.ID}) {
+					t.Fatalf("shared note still links %v", shared.ContactIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteRollsBack(t *testing.T) {
+	f := newFixture(t)
+	ctx := t.Context()
+	service := failingNotes{f.service}
+
+	plan, _ := NewPlan(ctx, service, *f.alice, *f.bob, nil, true)
+	result, err := Execute(ctx, service, plan)
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("got error %v, want a rollback", err)
+	}
+	if !result.RolledBack {
+		t.Fatal("result doesn't say it was rolled back")
+	}
+
+	if contacts, _ := f.service.ListContacts(ctx, f.bob.ID); len(contacts) != 0 {
+		t.Fatalf("bob kept %d copied contacts", len(contacts))
+	}
+	if contacts, _ := f.service.ListContacts(ctx, f.alice.ID); len(contacts) != 3 {
+		t.Fatalf("alice lost contacts: %d left", len(contacts))
+	}
+}